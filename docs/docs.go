@@ -35,28 +35,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboards": {
+        "/admin/users": {
             "get": {
-                "description": "Returns all dashboards for the current user.",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns all users owning dashboards with their dashboard count. Requires the admin role.\nAll dashboard and widget routes are also available below /admin/users/{userId} to act on behalf of that user.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dashboards"
+                    "admin"
                 ],
-                "summary": "List dashboards",
+                "summary": "List users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/lib.Dashboard"
+                                "$ref": "#/definitions/lib.UserSummary"
                             }
                         }
                     },
-                    "304": {
-                        "description": "Not Modified",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -68,28 +73,30 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/users/{userId}/reset": {
             "post": {
-                "description": "Creates a new dashboard for the current user.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Deletes all dashboards of a user and creates the default dashboard. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dashboards"
+                    "admin"
                 ],
-                "summary": "Create dashboard",
+                "summary": "Reset user",
                 "parameters": [
                     {
-                        "description": "Dashboard payload",
-                        "name": "dashboard",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/lib.Dashboard"
-                        }
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -99,8 +106,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/lib.Dashboard"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -114,40 +121,72 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboards/{id}": {
+        "/audit": {
             "get": {
-                "description": "Returns a dashboard by id.",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the audit entries of all writes, newest first. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dashboards"
+                    "admin"
                 ],
-                "summary": "Get dashboard",
+                "summary": "List audit entries",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Dashboard ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "name": "dashboard",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the acting user",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest time, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest time (exclusive), RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of entries, at most 1000",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/lib.Dashboard"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.AuditEntry"
+                            }
                         }
                     },
-                    "304": {
-                        "description": "Not Modified",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -159,9 +198,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Updates dashboard metadata by id.",
+            }
+        },
+        "/batch": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Runs an ordered list of write operations in a single transaction. Operations are requests to the\nPOST, PUT, PATCH and DELETE endpoints of this API with a JSON body and are authorized like separate\nrequests. If an operation fails, no operation is applied and the status of the failed operation is\nreturned. Each operation counts against the write rate limit, at most the burst size per batch.",
                 "consumes": [
                     "application/json"
                 ],
@@ -169,24 +215,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "dashboards"
+                    "batch"
                 ],
-                "summary": "Update dashboard",
+                "summary": "Run operations in a transaction",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Dashboard ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Dashboard payload",
-                        "name": "dashboard",
+                        "description": "Operations",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/lib.Dashboard"
+                            "$ref": "#/definitions/lib.BatchRequest"
                         }
                     }
                 ],
@@ -194,17 +233,29 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/lib.Dashboard"
+                            "$ref": "#/definitions/lib.BatchResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.BatchResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/lib.BatchResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/lib.BatchResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
                             "type": "string"
                         }
@@ -216,34 +267,53 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes a dashboard by id.",
+            }
+        },
+        "/dashboards": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns all dashboards of the current user, followed by the dashboards shared with the current user.\nWithout filter the dashboards are ordered by index, with a folder filter by their position inside the folder.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "dashboards"
                 ],
-                "summary": "Delete dashboard",
+                "summary": "List dashboards",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Dashboard ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Folder ID, or root for dashboards outside of folders",
+                        "name": "folder",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tags, all have to match",
+                        "name": "tag",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/lib.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.Dashboard"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "304": {
+                        "description": "Not Modified",
                         "schema": {
                             "type": "string"
                         }
@@ -255,37 +325,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/doc": {
-            "get": {
-                "description": "Returns the generated Swagger document for this service.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "documentation"
-                ],
-                "summary": "Get OpenAPI document",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "string"
-                        }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
                     }
-                }
-            }
-        },
-        "/widgets/name/{dashboardId}/{widgetId}": {
-            "patch": {
-                "description": "Updates the name of a widget.",
+                ],
+                "description": "Creates a new dashboard for the current user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -293,31 +340,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "widgets"
+                    "dashboards"
                 ],
-                "summary": "Update widget name",
+                "summary": "Create dashboard",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Dashboard ID",
-                        "name": "dashboardId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Widget ID",
-                        "name": "widgetId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "New widget name",
-                        "name": "name",
+                        "description": "Dashboard payload",
+                        "name": "dashboard",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Dashboard"
                         }
                     }
                 ],
@@ -325,7 +358,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/lib.Response"
+                            "$ref": "#/definitions/lib.Dashboard"
                         }
                     },
                     "400": {
@@ -334,8 +367,20 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
                             "type": "string"
                         }
@@ -349,38 +394,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/widgets/positions": {
-            "patch": {
-                "description": "Updates positions for multiple widgets.",
-                "consumes": [
-                    "application/json"
+        "/dashboards/changes": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Returns the dashboards created or updated and the dashboards and widgets deleted since the sync token,\nincluding dashboards shared with the current user. Without token, or if the token expired, all\ndashboards are returned with reset set. Changes may be returned more than once. Clients apply the\ndeletions first, then the dashboards, and pass next as since in the following request.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "widgets"
+                    "dashboards"
                 ],
-                "summary": "Update widget positions",
+                "summary": "List dashboard changes",
                 "parameters": [
                     {
-                        "description": "Widget position updates",
-                        "name": "positions",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/lib.WidgetPosition"
-                            }
-                        }
+                        "type": "string",
+                        "description": "Sync token of the previous response",
+                        "name": "since",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/lib.Response"
+                            "$ref": "#/definitions/lib.DashboardChanges"
                         }
                     },
                     "400": {
@@ -398,53 +439,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/widgets/properties/{dashboardId}/{widgetId}": {
-            "patch": {
-                "description": "Updates the complete properties object of a widget.",
-                "consumes": [
-                    "application/json"
+        "/dashboards/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Returns a dashboard by id.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "widgets"
+                    "dashboards"
                 ],
-                "summary": "Update widget properties",
+                "summary": "Get dashboard",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Dashboard ID",
-                        "name": "dashboardId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Widget ID",
-                        "name": "widgetId",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "New properties object",
-                        "name": "properties",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/lib.Response"
+                            "$ref": "#/definitions/lib.Dashboard"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "304": {
+                        "description": "Not Modified",
                         "schema": {
                             "type": "string"
                         }
@@ -462,50 +489,165 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/widgets/properties/{property}/{dashboardId}/{widgetId}": {
-            "patch": {
-                "description": "Updates a single widget property by property key.",
-                "consumes": [
-                    "application/json"
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates dashboard metadata, tags and variables by id. Owner, shares and folder are kept unchanged.",
+                "consumes": [
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "widgets"
+                    "dashboards"
                 ],
-                "summary": "Update one widget property",
+                "summary": "Update dashboard",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Property name",
-                        "name": "property",
+                        "description": "Dashboard ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "description": "Dashboard payload",
+                        "name": "dashboard",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/lib.Dashboard"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Dashboard"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes a dashboard by id. Only the owner may delete a dashboard.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboards"
+                ],
+                "summary": "Delete dashboard",
+                "parameters": [
                     {
                         "type": "string",
                         "description": "Dashboard ID",
-                        "name": "dashboardId",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dashboards/{id}/folder": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Moves a dashboard into a folder at the given position, or at the end without index. Use root as folder_id to move it out of all folders. Only the owner may move a dashboard.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Move dashboard to folder",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Widget ID",
-                        "name": "widgetId",
+                        "description": "Dashboard ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "New property value",
-                        "name": "value",
+                        "description": "Target folder and position",
+                        "name": "move",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/lib.DashboardFolderMove"
                         }
                     }
                 ],
@@ -522,6 +664,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -537,42 +685,55 @@ const docTemplate = `{
                 }
             }
         },
-        "/widgets/{dashboardId}": {
+        "/dashboards/{id}/layout/compact": {
             "post": {
-                "description": "Creates a new widget in a dashboard.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Moves all widgets of a dashboard up (vertical gravity) or left (horizontal gravity) to close gaps.\nWith dry_run the proposed positions are returned without saving them.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "widgets"
+                    "dashboards"
                 ],
-                "summary": "Create widget",
+                "summary": "Compact dashboard layout",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Dashboard ID",
-                        "name": "dashboardId",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Widget payload",
-                        "name": "widget",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/lib.Widget"
-                        }
+                        "enum": [
+                            "vertical",
+                            "horizontal"
+                        ],
+                        "type": "string",
+                        "default": "vertical",
+                        "description": "Compaction direction",
+                        "name": "gravity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the proposed positions",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/lib.Widget"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.WidgetLayout"
+                            }
                         }
                     },
                     "400": {
@@ -581,6 +742,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -596,41 +763,109 @@ const docTemplate = `{
                 }
             }
         },
-        "/widgets/{dashboardId}/{widgetId}": {
+        "/dashboards/{id}/links": {
             "get": {
-                "description": "Returns a widget by dashboard and widget id.",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns all public share links of a dashboard, including expired ones. Only the owner may list links.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "widgets"
+                    "share-links"
                 ],
-                "summary": "Get widget",
+                "summary": "List public share links",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Dashboard ID",
-                        "name": "dashboardId",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.ShareLink"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates a random, revocable token granting read-only access to a dashboard without authentication. Only the owner may create links.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share-links"
+                ],
+                "summary": "Create public share link",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Widget ID",
-                        "name": "widgetId",
+                        "description": "Dashboard ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Optional link expiry",
+                        "name": "link",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/lib.ShareLinkRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/lib.Widget"
+                            "$ref": "#/definitions/lib.ShareLink"
                         }
                     },
-                    "304": {
-                        "description": "Not Modified",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -648,28 +883,35 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/dashboards/{id}/links/{linkId}": {
             "delete": {
-                "description": "Deletes a widget by dashboard and widget id.",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes a public share link. Only the owner may revoke links.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "widgets"
+                    "share-links"
                 ],
-                "summary": "Delete widget",
+                "summary": "Revoke public share link",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Dashboard ID",
-                        "name": "dashboardId",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Widget ID",
-                        "name": "widgetId",
+                        "description": "Link ID",
+                        "name": "linkId",
                         "in": "path",
                         "required": true
                     }
@@ -681,6 +923,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/lib.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -695,30 +943,1655 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "lib.Dashboard": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "index": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "refresh_time": {
-                    "type": "integer"
-                },
+        },
+        "/dashboards/{id}/redo": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Reapplies the latest change of the current user reverted with undo. New changes discard the reverted\nchanges.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboards"
+                ],
+                "summary": "Redo dashboard change",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.UndoResult"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "nothing to redo or the restored widgets overlap others",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "the restored widgets are no longer valid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dashboards/{id}/resolved": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a dashboard where all variable references (\"${name}\") in widget properties are replaced.\nValues are passed as query parameters named var-\u003cname\u003e, variables without value use their default.\nTime ranges are given as \"from,to\", string lists as repeated or comma separated values.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboards"
+                ],
+                "summary": "Get dashboard with resolved variables",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Dashboard"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dashboards/{id}/shares": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the users a dashboard is shared with. Only the owner may read the shares.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboards"
+                ],
+                "summary": "List dashboard shares",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.DashboardShare"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replaces the users a dashboard is shared with. Viewers may only read the dashboard, editors may also change its widgets. Only the owner may change the shares.\nUsers losing their share no longer have the dashboard as home, last opened or favorite dashboard.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboards"
+                ],
+                "summary": "Update dashboard shares",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Complete list of shares",
+                        "name": "shares",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.DashboardShare"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.DashboardShare"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dashboards/{id}/undo": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Reverts the latest change of the current user to the widgets or metadata of the dashboard. Changes of\nother users to other widgets are kept. The number of changes kept per dashboard is limited. Moves of\nwidgets between dashboards are not undoable and discard the changes of the moved widget.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboards"
+                ],
+                "summary": "Undo dashboard change",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.UndoResult"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "nothing to undo or the restored widgets overlap others",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "the restored widgets are no longer valid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/doc": {
+            "get": {
+                "description": "Returns the generated Swagger document for this service.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "documentation"
+                ],
+                "summary": "Get OpenAPI document",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Streams Server-Sent Events for changes of the dashboards of the user and the dashboards shared with the user.\nThe event name is the event type, the data the event as JSON. Reconnecting clients send the id of the last\nreceived event as Last-Event-ID header or last_event_id query parameter to receive the missed events.\nIf these are no longer available, a resync event is sent and all dashboards have to be reloaded.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream change events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last received event, for clients that can not set headers",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/folders": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns all folders of the current user. Folders without parent_id are top level folders.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "List folders",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.Folder"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates a folder, optionally inside a parent folder. The index is the position among the sibling\nfolders, the indices of the siblings are renumbered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Create folder",
+                "parameters": [
+                    {
+                        "description": "Folder payload",
+                        "name": "folder",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/lib.Folder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Folder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/folders/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a folder by id.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Get folder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Folder ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Folder"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Renames, reorders or moves a folder. A folder can not be moved into itself or its sub folders. The\nindex is the position among the sibling folders, the indices of the siblings are renumbered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Update folder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Folder ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Folder payload",
+                        "name": "folder",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/lib.Folder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Folder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes a folder. Its sub folders and dashboards are moved to the parent folder.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Delete folder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Folder ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/live": {
+            "get": {
+                "description": "Returns up while the process is able to handle requests. Does not check the database.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "Liveness check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.HealthStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "description": "Checks the database connection and the migration status. Returns 503 if any check is down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "Readiness check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.HealthStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/lib.HealthStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the preferences of the user. Dashboards deleted since are removed automatically.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Get preferences",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Preferences"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replaces the preferences of the user. All referenced dashboards have to be readable by the user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "preferences"
+                ],
+                "summary": "Update preferences",
+                "parameters": [
+                    {
+                        "description": "Preferences payload",
+                        "name": "preferences",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/lib.Preferences"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Preferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/public/dashboards/{token}": {
+            "get": {
+                "description": "Returns the dashboard of a public share link read-only. Does not require authentication. User ids are removed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share-links"
+                ],
+                "summary": "Get shared dashboard",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share link token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Dashboard"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/quota": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the configured limits and the current usage of the user. A limit of 0 is unlimited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quota"
+                ],
+                "summary": "Get quota",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.QuotaStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/widget-types": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns all known widget types with the JSON Schema their properties are validated against. Variable\nreferences are validated as the default of the variable, or an empty value of its type.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "widgets"
+                ],
+                "summary": "List widget types",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.WidgetType"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/widgets": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns all widgets of the current user matching the given type and name, together with their dashboard.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "widgets"
+                ],
+                "summary": "Search widgets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Widget type (exact match)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Widget name (case-insensitive substring match)",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.WidgetSearchResult"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/widgets/name/{dashboardId}/{widgetId}": {
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates the name of a widget.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "widgets"
+                ],
+                "summary": "Update widget name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "dashboardId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Widget ID",
+                        "name": "widgetId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New widget name",
+                        "name": "name",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/widgets/positions": {
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates positions for multiple widgets. If a breakpoint is given, the layout of the widget for that breakpoint is updated instead of the default layout. Positions are validated against the grid, widgets without coordinates are placed into the first free slot.\nCollisions are checked after all positions of a dashboard are applied, so widgets can swap places. Overlapping widgets are pushed down or rejected with 409, depending on the configured collision mode.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "widgets"
+                ],
+                "summary": "Update widget positions",
+                "parameters": [
+                    {
+                        "description": "Widget position updates",
+                        "name": "positions",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.WidgetPosition"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/widgets/properties/{dashboardId}/{widgetId}": {
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates the complete properties object of a widget.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "widgets"
+                ],
+                "summary": "Update widget properties",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "dashboardId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Widget ID",
+                        "name": "widgetId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New properties object",
+                        "name": "properties",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/widgets/properties/{property}/{dashboardId}/{widgetId}": {
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates a single widget property by property key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "widgets"
+                ],
+                "summary": "Update one widget property",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Property name",
+                        "name": "property",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "dashboardId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Widget ID",
+                        "name": "widgetId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New property value",
+                        "name": "value",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/widgets/{dashboardId}": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates a new widget in a dashboard. Widgets without coordinates are placed into the first free slot of the grid.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "widgets"
+                ],
+                "summary": "Create widget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "dashboardId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Widget payload",
+                        "name": "widget",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/lib.Widget"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Widget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/widgets/{dashboardId}/{widgetId}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns a widget by dashboard and widget id.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "widgets"
+                ],
+                "summary": "Get widget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "dashboardId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Widget ID",
+                        "name": "widgetId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Widget"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes a widget by dashboard and widget id.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "widgets"
+                ],
+                "summary": "Delete widget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dashboard ID",
+                        "name": "dashboardId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Widget ID",
+                        "name": "widgetId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "lib.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "admin_id": {
+                    "type": "string"
+                },
+                "after": {
+                    "$ref": "#/definitions/lib.AuditSummary"
+                },
+                "before": {
+                    "$ref": "#/definitions/lib.AuditSummary"
+                },
+                "client_ip": {
+                    "type": "string"
+                },
+                "dashboard_id": {
+                    "type": "string"
+                },
+                "folder_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "link_id": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "widget_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.AuditSummary": {
+            "type": "object",
+            "properties": {
+                "folder_id": {
+                    "type": "string"
+                },
+                "layout": {
+                    "$ref": "#/definitions/lib.WidgetLayout"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.DashboardShare"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "widgets": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lib.BatchOperation": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "object"
+                },
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "POST",
+                        "PUT",
+                        "PATCH",
+                        "DELETE"
+                    ]
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.BatchOperation"
+                    }
+                }
+            }
+        },
+        "lib.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "description": "Results are in the order of the operations. After a failure, the failed operation is the last one.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.BatchResult"
+                    }
+                },
+                "rolled_back": {
+                    "description": "RolledBack is true if an operation failed and none of the operations were applied.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "lib.BatchResult": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "object"
+                },
+                "error": {
+                    "description": "Error is the error message of a failed operation.",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lib.BreakpointLayout": {
+            "type": "object",
+            "properties": {
+                "derived": {
+                    "description": "Derived is set for layouts generated by the server because the widget has no explicit layout for the breakpoint.",
+                    "type": "boolean"
+                },
+                "h": {
+                    "type": "integer"
+                },
+                "w": {
+                    "type": "integer"
+                },
+                "x": {
+                    "type": "integer"
+                },
+                "y": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lib.Dashboard": {
+            "type": "object",
+            "properties": {
+                "folder_id": {
+                    "type": "string"
+                },
+                "folder_index": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "refresh_time": {
+                    "type": "integer"
+                },
+                "shared": {
+                    "type": "boolean"
+                },
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.DashboardShare"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "updatedAt": {
                     "type": "string"
                 },
                 "user_id": {
                     "type": "string"
                 },
+                "variables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.DashboardVariable"
+                    }
+                },
                 "widgets": {
                     "type": "array",
                     "items": {
@@ -727,6 +2600,223 @@ const docTemplate = `{
                 }
             }
         },
+        "lib.DashboardChanges": {
+            "type": "object",
+            "properties": {
+                "dashboards": {
+                    "description": "Dashboards are created or updated since the token, in their current state.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.Dashboard"
+                    }
+                },
+                "deleted": {
+                    "description": "Deleted has to be applied before Dashboards.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.Tombstone"
+                    }
+                },
+                "next": {
+                    "description": "Next is the token for the following request.",
+                    "type": "string"
+                },
+                "reset": {
+                    "description": "Reset is true if no or an expired token was given. Dashboards then contains all dashboards of the user and local\ndashboards missing in it have to be removed.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "lib.DashboardFolderMove": {
+            "type": "object",
+            "properties": {
+                "folder_id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lib.DashboardShare": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "viewer",
+                        "editor"
+                    ]
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.DashboardVariable": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "type": "object"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "time_range",
+                        "device",
+                        "string_list"
+                    ]
+                }
+            }
+        },
+        "lib.Event": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "string"
+                },
+                "dashboard_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "widget_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.Folder": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.HealthStatus": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/lib.HealthCheck"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.Preferences": {
+            "type": "object",
+            "properties": {
+                "default_refresh_time": {
+                    "type": "integer"
+                },
+                "favorite_dashboard_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "home_dashboard_id": {
+                    "type": "string"
+                },
+                "last_opened_dashboard_id": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.Quota": {
+            "type": "object",
+            "properties": {
+                "max_batch_operations": {
+                    "type": "integer"
+                },
+                "max_dashboards_per_user": {
+                    "type": "integer"
+                },
+                "max_properties_depth": {
+                    "type": "integer"
+                },
+                "max_properties_size": {
+                    "type": "integer"
+                },
+                "max_request_body_size": {
+                    "type": "integer"
+                },
+                "max_widgets_per_dashboard": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lib.QuotaStatus": {
+            "type": "object",
+            "properties": {
+                "limits": {
+                    "$ref": "#/definitions/lib.Quota"
+                },
+                "usage": {
+                    "$ref": "#/definitions/lib.QuotaUsage"
+                }
+            }
+        },
+        "lib.QuotaUsage": {
+            "type": "object",
+            "properties": {
+                "dashboards": {
+                    "type": "integer"
+                },
+                "widgets_per_dashboard": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
         "lib.Response": {
             "type": "object",
             "properties": {
@@ -735,6 +2825,89 @@ const docTemplate = `{
                 }
             }
         },
+        "lib.ShareLink": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "dashboard_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.ShareLinkRequest": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.Tombstone": {
+            "type": "object",
+            "properties": {
+                "dashboard_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "widget_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.UndoResult": {
+            "type": "object",
+            "properties": {
+                "can_redo": {
+                    "type": "boolean"
+                },
+                "can_undo": {
+                    "type": "boolean"
+                },
+                "dashboard": {
+                    "$ref": "#/definitions/lib.Dashboard"
+                },
+                "metadata": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "Type is the event type of the reverted or reapplied change.",
+                    "type": "string"
+                },
+                "widget_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "lib.UserSummary": {
+            "type": "object",
+            "properties": {
+                "dashboards": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
         "lib.Widget": {
             "type": "object",
             "properties": {
@@ -744,6 +2917,13 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "layouts": {
+                    "description": "Layouts holds the layout per breakpoint name, X, Y, W and H are the default layout.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/lib.BreakpointLayout"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
@@ -762,9 +2942,33 @@ const docTemplate = `{
                 }
             }
         },
+        "lib.WidgetLayout": {
+            "type": "object",
+            "properties": {
+                "h": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "w": {
+                    "type": "integer"
+                },
+                "x": {
+                    "type": "integer"
+                },
+                "y": {
+                    "type": "integer"
+                }
+            }
+        },
         "lib.WidgetPosition": {
             "type": "object",
             "properties": {
+                "breakpoint": {
+                    "description": "Breakpoint selects the breakpoint layout to update instead of the default layout.",
+                    "type": "string"
+                },
                 "dashboardDestination": {
                     "type": "string"
                 },
@@ -787,6 +2991,31 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "lib.WidgetSearchResult": {
+            "type": "object",
+            "properties": {
+                "dashboard_id": {
+                    "type": "string"
+                },
+                "dashboard_name": {
+                    "type": "string"
+                },
+                "widget": {
+                    "$ref": "#/definitions/lib.Widget"
+                }
+            }
+        },
+        "lib.WidgetType": {
+            "type": "object",
+            "properties": {
+                "schema": {
+                    "type": "object"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -0,0 +1,110 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const adminIdContextKey = "adminId"
+
+type UserSummary struct {
+	UserId     string `bson:"_id" json:"user_id"`
+	Dashboards int    `bson:"dashboards" json:"dashboards"`
+}
+
+// adminHandler rejects callers without the admin role and logs every admin request with the acting admin id.
+func adminHandler(c *gin.Context) {
+	adminId := getUserId(c)
+	if !slices.Contains(getUserRoles(c), Config.AdminRole) {
+		abortWithError(c, errors.Join(ErrForbidden, errors.New("admin role required")))
+		return
+	}
+	c.Set(adminIdContextKey, adminId)
+	getAuditContext(c.Request.Context()).AdminId = adminId
+	c.Next()
+	log.Logger.Info("admin action",
+		"admin_id", adminId,
+		"user_id", c.Param("userId"),
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"status", c.Writer.Status(),
+	)
+}
+
+// isImpersonated is true for requests of an admin acting as another user.
+func isImpersonated(c *gin.Context) bool {
+	return c.GetString(adminIdContextKey) != ""
+}
+
+// impersonationHandler lets the following handlers act as the user given by the userId path parameter.
+func impersonationHandler(c *gin.Context) {
+	c.Set(userIdContextKey, c.Param("userId"))
+	c.Set(userRolesContextKey, []string{})
+	c.Next()
+}
+
+func listUsers(ctx context.Context) (result []UserSummary, err error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$userid", "dashboards": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cur, err := Mongo().Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Logger.Error("list users failed", attributes.ErrorKey, err)
+		return nil, normalizeModelError(err)
+	}
+	result = []UserSummary{}
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, normalizeModelError(err)
+	}
+	return result, nil
+}
+
+// resetUserDashboards deletes all dashboards owned by the user and creates the default dashboard.
+func resetUserDashboards(ctx context.Context, userId string) (result Dashboard, err error) {
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		cur, err := Mongo().Find(ctx, bson.M{"userid": userId})
+		if err != nil {
+			return normalizeModelError(err)
+		}
+		var dashs []Dashboard
+		if err = cur.All(ctx, &dashs); err != nil {
+			return normalizeModelError(err)
+		}
+		for _, dash := range dashs {
+			_, err = deleteDashboard(ctx, dash.Id.Hex(), userId)
+			if err != nil {
+				return err
+			}
+		}
+		result, err = createDefaultDashboard(ctx, userId)
+		return normalizeModelError(err)
+	})
+	if err != nil {
+		return Dashboard{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,199 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AuditFolderCreated      = "folder_created"
+	AuditFolderUpdated      = "folder_updated"
+	AuditFolderDeleted      = "folder_deleted"
+	AuditShareLinkCreated   = "share_link_created"
+	AuditShareLinkDeleted   = "share_link_deleted"
+	AuditPreferencesUpdated = "preferences_updated"
+)
+
+// AuditEntry records a single write. Dashboard and widget changes use the event types as action.
+type AuditEntry struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	Time        time.Time          `json:"time"`
+	Action      string             `json:"action"`
+	ActorId     string             `json:"actor_id"`
+	AdminId     string             `json:"admin_id,omitempty"`
+	DashboardId string             `json:"dashboard_id,omitempty"`
+	WidgetId    string             `json:"widget_id,omitempty"`
+	FolderId    string             `json:"folder_id,omitempty"`
+	LinkId      string             `json:"link_id,omitempty"`
+	RequestId   string             `json:"request_id,omitempty"`
+	ClientIp    string             `json:"client_ip,omitempty"`
+	Before      *AuditSummary      `json:"before,omitempty"`
+	After       *AuditSummary      `json:"after,omitempty"`
+}
+
+// AuditSummary describes the audited object without its properties.
+type AuditSummary struct {
+	Name     string           `json:"name,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Widgets  *int             `json:"widgets,omitempty"`
+	FolderId string           `json:"folder_id,omitempty"`
+	Shares   []DashboardShare `json:"shares,omitempty"`
+	Layout   *WidgetLayout    `json:"layout,omitempty"`
+}
+
+type AuditQuery struct {
+	DashboardId string
+	ActorId     string
+	From        *time.Time
+	To          *time.Time
+	Limit       int64
+}
+
+type auditContextKey struct{}
+
+// auditContext holds the request details of the audit entries written while handling a request.
+type auditContext struct {
+	RequestId string
+	ClientIp  string
+	AdminId   string
+}
+
+func auditHandler(c *gin.Context) {
+	info := &auditContext{RequestId: requestid.Get(c), ClientIp: c.ClientIP()}
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), auditContextKey{}, info))
+	c.Next()
+}
+
+func getAuditContext(ctx context.Context) *auditContext {
+	info, ok := ctx.Value(auditContextKey{}).(*auditContext)
+	if !ok {
+		return &auditContext{}
+	}
+	return info
+}
+
+func summarizeDashboard(dash *Dashboard) *AuditSummary {
+	if dash == nil {
+		return nil
+	}
+	widgets := len(dash.Widgets)
+	return &AuditSummary{Name: dash.Name, Widgets: &widgets, FolderId: dash.FolderId, Shares: dash.Shares}
+}
+
+func summarizeWidget(dash *Dashboard, widgetId string) *AuditSummary {
+	if dash == nil {
+		return nil
+	}
+	id, err := primitive.ObjectIDFromHex(widgetId)
+	if err != nil {
+		return nil
+	}
+	_, widget, err := dash.GetWidget(id)
+	if err != nil {
+		return nil
+	}
+	return &AuditSummary{
+		Name:   widget.Name,
+		Type:   widget.Type,
+		Layout: &WidgetLayout{Id: widget.Id, X: widget.X, Y: widget.Y, W: widget.W, H: widget.H},
+	}
+}
+
+func newDashboardAuditEntry(action string, before *Dashboard, after *Dashboard, widgetId string, actorId string) AuditEntry {
+	entry := AuditEntry{Action: action, ActorId: actorId, WidgetId: widgetId}
+	if widgetId != "" {
+		entry.Before, entry.After = summarizeWidget(before, widgetId), summarizeWidget(after, widgetId)
+	} else {
+		entry.Before, entry.After = summarizeDashboard(before), summarizeDashboard(after)
+	}
+	if after != nil {
+		entry.DashboardId = after.Id.Hex()
+	} else if before != nil {
+		entry.DashboardId = before.Id.Hex()
+	}
+	return entry
+}
+
+// recordAudit appends the entry to the audit log, completed with the request details of ctx.
+func recordAudit(ctx context.Context, entry AuditEntry) error {
+	info := getAuditContext(ctx)
+	entry.Id = primitive.NewObjectID()
+	entry.Time = time.Now()
+	entry.RequestId = info.RequestId
+	entry.ClientIp = info.ClientIp
+	entry.AdminId = info.AdminId
+	_, err := MongoAudit().InsertOne(ctx, entry)
+	if err != nil {
+		log.Logger.Error("write audit entry failed", attributes.ErrorKey, err)
+		return normalizeModelError(err)
+	}
+	return nil
+}
+
+func listAuditEntries(ctx context.Context, query AuditQuery) (result []AuditEntry, err error) {
+	filter := bson.M{}
+	if query.DashboardId != "" {
+		filter["dashboardid"] = query.DashboardId
+	}
+	if query.ActorId != "" {
+		filter["actorid"] = query.ActorId
+	}
+	timeFilter := bson.M{}
+	if query.From != nil {
+		timeFilter["$gte"] = *query.From
+	}
+	if query.To != nil {
+		timeFilter["$lt"] = *query.To
+	}
+	if len(timeFilter) > 0 {
+		filter["time"] = timeFilter
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(query.Limit)
+	cur, err := MongoAudit().Find(ctx, filter, opts)
+	if err != nil {
+		log.Logger.Error("find audit entries failed", attributes.ErrorKey, err)
+		return nil, normalizeModelError(err)
+	}
+	result = []AuditEntry{}
+	if err = cur.All(ctx, &result); err != nil {
+		return nil, normalizeModelError(err)
+	}
+	return result, nil
+}
+
+// createAuditIndices creates the query indices and applies the retention.
+func createAuditIndices(ctx context.Context) error {
+	_, err := MongoAudit().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "dashboardid", Value: 1}, {Key: "time", Value: -1}}},
+		{Keys: bson.D{{Key: "actorid", Value: 1}, {Key: "time", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+	return ensureTTLIndex(ctx, MongoAudit(), "time", Config.AuditRetention)
+}
@@ -0,0 +1,284 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	userIdContextKey    = "userId"
+	userRolesContextKey = "userRoles"
+)
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Roles       []string `json:"roles,omitempty"`
+	RealmAccess struct {
+		Roles []string `json:"roles,omitempty"`
+	} `json:"realm_access,omitempty"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type verificationKeys struct {
+	byKid map[string]jwt.VerificationKey
+	all   []jwt.VerificationKey
+}
+
+func (this *verificationKeys) add(kid string, key jwt.VerificationKey) {
+	if kid != "" {
+		this.byKid[kid] = key
+	}
+	this.all = append(this.all, key)
+}
+
+func (this *verificationKeys) keyFunc(token *jwt.Token) (interface{}, error) {
+	if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+		if key, ok := this.byKid[kid]; ok {
+			return key, nil
+		}
+		if len(this.byKid) > 0 {
+			return nil, fmt.Errorf("unknown key id %s", kid)
+		}
+	}
+	return jwt.VerificationKeySet{Keys: this.all}, nil
+}
+
+func loadVerificationKeys() (keys *verificationKeys, err error) {
+	keys = &verificationKeys{byKid: map[string]jwt.VerificationKey{}}
+	if Config.JwksFile != "" {
+		err = keys.loadJwksFile(Config.JwksFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load jwks file: %w", err)
+		}
+	}
+	if Config.JwtPublicKey != "" {
+		err = keys.loadPublicKeys(Config.JwtPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not load jwt public key: %w", err)
+		}
+	}
+	if len(keys.all) == 0 {
+		return nil, errors.New("no jwt verification keys configured, set JWT_JWKS_FILE or JWT_PUBLIC_KEY")
+	}
+	return keys, nil
+}
+
+func (this *verificationKeys) loadJwksFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err = json.Unmarshal(content, &jwks)
+	if err != nil {
+		return err
+	}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return fmt.Errorf("key %s: %w", jwk.Kid, err)
+		}
+		this.add(jwk.Kid, key)
+	}
+	return nil
+}
+
+// loadPublicKeys accepts one or more PEM encoded public keys or a single base64 encoded DER key as published by Keycloak.
+func (this *verificationKeys) loadPublicKeys(value string) error {
+	if !strings.Contains(value, "-----BEGIN") {
+		der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		key, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return err
+		}
+		this.add("", key)
+		return nil
+	}
+	rest := []byte(value)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil
+		}
+		var key interface{}
+		var err error
+		switch block.Type {
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			cert, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				key = cert.PublicKey
+			}
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		default:
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		}
+		if err != nil {
+			return err
+		}
+		this.add("", key)
+	}
+}
+
+func (this *jsonWebKey) publicKey() (jwt.VerificationKey, error) {
+	switch this.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(this.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(this.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch this.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", this.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(this.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(this.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", this.Kty)
+	}
+}
+
+// authHandler resolves the identity of the caller and rejects unauthenticated requests.
+// The user id and roles are stored in the gin context, see getUserId and getUserRoles. Batch operations take the
+// identity of their batch request.
+func authHandler() gin.HandlerFunc {
+	if Config.AuthDevMode {
+		log.Logger.Warn("auth dev mode enabled, trusting X-UserId header without verification")
+		return devAuthHandler
+	}
+	keys, err := loadVerificationKeys()
+	if err != nil {
+		panic(err)
+	}
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if Config.JwtIssuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(Config.JwtIssuer))
+	}
+	if Config.JwtAudience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(Config.JwtAudience))
+	}
+	parser := jwt.NewParser(parserOpts...)
+
+	return func(c *gin.Context) {
+		if setBatchIdentity(c) {
+			c.Next()
+			return
+		}
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			tokenString, found = strings.CutPrefix(header, "bearer ")
+		}
+		if !found || tokenString == "" {
+			abortWithError(c, errors.Join(ErrUnauthorized, errors.New("missing bearer token")))
+			return
+		}
+		claims := &tokenClaims{}
+		_, err := parser.ParseWithClaims(tokenString, claims, keys.keyFunc)
+		if err != nil {
+			abortWithError(c, errors.Join(ErrUnauthorized, err))
+			return
+		}
+		if claims.Subject == "" {
+			abortWithError(c, errors.Join(ErrUnauthorized, errors.New("token has no subject")))
+			return
+		}
+		c.Set(userIdContextKey, claims.Subject)
+		c.Set(userRolesContextKey, append(claims.Roles, claims.RealmAccess.Roles...))
+		c.Next()
+	}
+}
+
+func devAuthHandler(c *gin.Context) {
+	if setBatchIdentity(c) {
+		c.Next()
+		return
+	}
+	userId := strings.ReplaceAll(c.GetHeader("X-UserId"), "\"", "")
+	if userId == "" {
+		abortWithError(c, errors.Join(ErrUnauthorized, errors.New("missing X-UserId header")))
+		return
+	}
+	roles := []string{}
+	for _, role := range strings.Split(c.GetHeader("X-User-Roles"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	c.Set(userIdContextKey, userId)
+	c.Set(userRolesContextKey, roles)
+	c.Next()
+}
@@ -0,0 +1,208 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testSigningKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func testToken(t *testing.T, key interface{}, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// testAuthRouter answers authenticated requests with the user id.
+func testAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", authHandler(), func(c *gin.Context) {
+		c.String(http.StatusOK, getUserId(c))
+	})
+	return router
+}
+
+func TestAuthHandler(t *testing.T) {
+	key := testSigningKey(t)
+	otherKey := testSigningKey(t)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := Config
+	defer func() { Config = original }()
+	Config.AuthDevMode = false
+	Config.JwksFile = ""
+	Config.JwtPublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	Config.JwtIssuer = "https://auth.example.com"
+	Config.JwtAudience = "dashboard"
+	router := testAuthRouter()
+
+	claims := func(changes jwt.MapClaims) jwt.MapClaims {
+		result := jwt.MapClaims{
+			"sub": "user-1",
+			"iss": "https://auth.example.com",
+			"aud": "dashboard",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(result, name)
+			} else {
+				result[name] = value
+			}
+		}
+		return result
+	}
+	tests := []struct {
+		name     string
+		header   map[string]string
+		batch    *batchIdentity
+		wantCode int
+		wantUser string
+	}{
+		{
+			name:     "valid token",
+			header:   map[string]string{"Authorization": "Bearer " + testToken(t, key, jwt.SigningMethodRS256, claims(nil))},
+			wantCode: http.StatusOK,
+			wantUser: "user-1",
+		},
+		{
+			name:     "missing token",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "bad signature",
+			header:   map[string]string{"Authorization": "Bearer " + testToken(t, otherKey, jwt.SigningMethodRS256, claims(nil))},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "symmetric signature",
+			header:   map[string]string{"Authorization": "Bearer " + testToken(t, []byte("secret"), jwt.SigningMethodHS256, claims(nil))},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "expired token",
+			header:   map[string]string{"Authorization": "Bearer " + testToken(t, key, jwt.SigningMethodRS256, claims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}))},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "token without expiration",
+			header:   map[string]string{"Authorization": "Bearer " + testToken(t, key, jwt.SigningMethodRS256, claims(jwt.MapClaims{"exp": nil}))},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "wrong issuer",
+			header:   map[string]string{"Authorization": "Bearer " + testToken(t, key, jwt.SigningMethodRS256, claims(jwt.MapClaims{"iss": "https://other.example.com"}))},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "wrong audience",
+			header:   map[string]string{"Authorization": "Bearer " + testToken(t, key, jwt.SigningMethodRS256, claims(jwt.MapClaims{"aud": "other"}))},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "token without subject",
+			header:   map[string]string{"Authorization": "Bearer " + testToken(t, key, jwt.SigningMethodRS256, claims(jwt.MapClaims{"sub": nil}))},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "user id header is ignored without dev mode",
+			header:   map[string]string{"X-UserId": "user-1"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "batch operation takes the identity of the batch",
+			batch:    &batchIdentity{userId: "user-2"},
+			wantCode: http.StatusOK,
+			wantUser: "user-2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range test.header {
+				req.Header.Set(name, value)
+			}
+			if test.batch != nil {
+				req = req.WithContext(context.WithValue(req.Context(), batchContextKey{}, *test.batch))
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != test.wantCode {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, test.wantCode, rec.Body.String())
+			}
+			if test.wantCode == http.StatusOK && rec.Body.String() != test.wantUser {
+				t.Errorf("got user %q, want %q", rec.Body.String(), test.wantUser)
+			}
+		})
+	}
+}
+
+func TestDevAuthHandler(t *testing.T) {
+	original := Config
+	defer func() { Config = original }()
+	Config.AuthDevMode = true
+	router := testAuthRouter()
+
+	tests := []struct {
+		name     string
+		userId   string
+		wantCode int
+	}{
+		{name: "user id header", userId: "user-1", wantCode: http.StatusOK},
+		{name: "quoted user id header", userId: `"user-1"`, wantCode: http.StatusOK},
+		{name: "missing user id header", wantCode: http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.userId != "" {
+				req.Header.Set("X-UserId", test.userId)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != test.wantCode {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, test.wantCode, rec.Body.String())
+			}
+			if test.wantCode == http.StatusOK && rec.Body.String() != "user-1" {
+				t.Errorf("got user %q, want %q", rec.Body.String(), "user-1")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var batchMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+type batchContextKey struct{}
+
+// batchIdentity is the authenticated caller of a batch request, passed to its operations in the request context.
+// Clients can not set context values, so the operations are not authenticated again.
+type batchIdentity struct {
+	userId string
+	roles  []string
+}
+
+func isBatchOperation(c *gin.Context) bool {
+	_, ok := c.Request.Context().Value(batchContextKey{}).(batchIdentity)
+	return ok
+}
+
+// setBatchIdentity sets the identity of the batch request for its operations, false for other requests.
+func setBatchIdentity(c *gin.Context) bool {
+	identity, ok := c.Request.Context().Value(batchContextKey{}).(batchIdentity)
+	if ok {
+		c.Set(userIdContextKey, identity.userId)
+		c.Set(userRolesContextKey, identity.roles)
+	}
+	return ok
+}
+
+// BatchOperation is a request to one of the write endpoints, e.g. {"method": "PATCH", "path": "/widgets/name/<dashboardId>/<widgetId>", "body": {...}}.
+type BatchOperation struct {
+	Method string          `json:"method" enums:"POST,PUT,PATCH,DELETE"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+}
+
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+type BatchResult struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+	// Error is the error message of a failed operation.
+	Error string `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	// Results are in the order of the operations. After a failure, the failed operation is the last one.
+	Results []BatchResult `json:"results"`
+	// RolledBack is true if an operation failed and none of the operations were applied.
+	RolledBack bool `json:"rolled_back"`
+}
+
+// batchResponseWriter records the response of an operation.
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (this *batchResponseWriter) Header() http.Header {
+	return this.header
+}
+
+func (this *batchResponseWriter) Write(data []byte) (int, error) {
+	if this.status == 0 {
+		this.status = http.StatusOK
+	}
+	return this.body.Write(data)
+}
+
+func (this *batchResponseWriter) WriteHeader(status int) {
+	if this.status == 0 {
+		this.status = status
+	}
+}
+
+func (this *batchResponseWriter) result() BatchResult {
+	result := BatchResult{Status: this.status}
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+	if this.body.Len() == 0 {
+		return result
+	}
+	if json.Valid(this.body.Bytes()) {
+		result.Body = json.RawMessage(bytes.Clone(this.body.Bytes()))
+	} else {
+		result.Error = strings.TrimSpace(this.body.String())
+	}
+	return result
+}
+
+func validateBatchRequest(request BatchRequest) error {
+	if len(request.Operations) == 0 {
+		return errors.Join(ErrBadRequest, errors.New("no operations"))
+	}
+	limit := Config.Quota.MaxBatchOperations
+	if limit > 0 && len(request.Operations) > limit {
+		return errors.Join(ErrBadRequest, fmt.Errorf("more than %d operations", limit))
+	}
+	for i, operation := range request.Operations {
+		if !slices.Contains(batchMethods, operation.Method) {
+			return errors.Join(ErrBadRequest, fmt.Errorf("operation %d: method %q not allowed", i, operation.Method))
+		}
+		if !strings.HasPrefix(operation.Path, "/") || strings.HasPrefix(operation.Path, "/batch") {
+			return errors.Join(ErrBadRequest, fmt.Errorf("operation %d: invalid path %q", i, operation.Path))
+		}
+	}
+	return nil
+}
+
+// runBatch passes the operations to handler in one transaction. The operations act as the identity of the batch
+// request. The first failing operation rolls back the transaction.
+func runBatch(ctx context.Context, handler http.Handler, original *http.Request, identity batchIdentity, request BatchRequest) (response BatchResponse, err error) {
+	failed := errors.New("batch operation failed")
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		// the transaction may be retried
+		response = BatchResponse{Results: []BatchResult{}}
+		ctx = context.WithValue(ctx, batchContextKey{}, identity)
+		for _, operation := range request.Operations {
+			req, err := http.NewRequestWithContext(ctx, operation.Method, operation.Path, bytes.NewReader(operation.Body))
+			if err != nil {
+				return errors.Join(ErrBadRequest, err)
+			}
+			req.Header = original.Header.Clone()
+			req.Header.Del("Content-Length")
+			req.Header.Set("Content-Type", "application/json")
+			req.ContentLength = int64(len(operation.Body))
+			req.RemoteAddr = original.RemoteAddr
+			writer := &batchResponseWriter{header: http.Header{}}
+			handler.ServeHTTP(writer, req)
+			result := writer.result()
+			response.Results = append(response.Results, result)
+			if result.Status >= http.StatusBadRequest {
+				return failed
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, failed) {
+		response.RolledBack = true
+		return response, nil
+	}
+	return response, err
+}
@@ -0,0 +1,84 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestValidateBatchRequest(t *testing.T) {
+	original := Config
+	defer func() { Config = original }()
+	Config.Quota = Quota{MaxBatchOperations: 2}
+
+	tests := []struct {
+		name       string
+		operations []BatchOperation
+		valid      bool
+	}{
+		{name: "valid operations", operations: []BatchOperation{{Method: http.MethodPost, Path: "/dashboards"}, {Method: http.MethodDelete, Path: "/dashboards/id"}}, valid: true},
+		{name: "no operations"},
+		{name: "too many operations", operations: []BatchOperation{{Method: http.MethodPost, Path: "/a"}, {Method: http.MethodPost, Path: "/b"}, {Method: http.MethodPost, Path: "/c"}}},
+		{name: "read operation", operations: []BatchOperation{{Method: http.MethodGet, Path: "/dashboards"}}},
+		{name: "relative path", operations: []BatchOperation{{Method: http.MethodPost, Path: "dashboards"}}},
+		{name: "nested batch", operations: []BatchOperation{{Method: http.MethodPost, Path: "/batch"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateBatchRequest(BatchRequest{Operations: test.operations})
+			if test.valid && err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if !test.valid && !errors.Is(err, ErrBadRequest) {
+				t.Fatalf("got error %v, want %v", err, ErrBadRequest)
+			}
+		})
+	}
+}
+
+func TestBatchResponseWriterResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+		wantBody   string
+		wantError  string
+	}{
+		{name: "empty response", wantStatus: http.StatusOK},
+		{name: "status only", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "json body", status: http.StatusCreated, body: `{"id":"a"}`, wantStatus: http.StatusCreated, wantBody: `{"id":"a"}`},
+		{name: "error message", status: http.StatusNotFound, body: "not found\n", wantStatus: http.StatusNotFound, wantError: "not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			writer := &batchResponseWriter{header: http.Header{}}
+			if test.status != 0 {
+				writer.WriteHeader(test.status)
+			}
+			if test.body != "" {
+				_, _ = writer.Write([]byte(test.body))
+			}
+			result := writer.result()
+			if result.Status != test.wantStatus || string(result.Body) != test.wantBody || result.Error != test.wantError {
+				t.Errorf("got %d %q %q, want %d %q %q", result.Status, result.Body, result.Error, test.wantStatus, test.wantBody, test.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CacheControlConfig struct {
+	// Private is sent with responses of authenticated routes.
+	Private string
+	// Public is sent with responses of routes without authentication, like public share links.
+	Public string
+}
+
+// bufferedResponseWriter holds the body back until the handler is done, so the ETag can be computed. Flushing
+// switches to streaming, responses flushed by the handler get no ETag.
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	streaming bool
+}
+
+func (this *bufferedResponseWriter) Write(data []byte) (int, error) {
+	if this.streaming {
+		return this.ResponseWriter.Write(data)
+	}
+	return this.body.Write(data)
+}
+
+func (this *bufferedResponseWriter) WriteString(s string) (int, error) {
+	if this.streaming {
+		return this.ResponseWriter.WriteString(s)
+	}
+	return this.body.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to clear the write deadline of event streams.
+func (this *bufferedResponseWriter) Unwrap() http.ResponseWriter {
+	return this.ResponseWriter
+}
+
+func (this *bufferedResponseWriter) Flush() {
+	if !this.streaming {
+		this.streaming = true
+		_, _ = this.ResponseWriter.Write(this.body.Bytes())
+		this.body.Reset()
+	}
+	this.ResponseWriter.Flush()
+}
+
+// computeETag returns a strong ETag of the body. Responses are rendered by encoding/json, which writes struct
+// fields in declaration order and map keys sorted, so equal content results in equal bodies.
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`
+}
+
+// matchesETag evaluates an If-None-Match header with the weak comparison required for GET requests.
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionalGetHandler adds an ETag and the cache policy to successful GET responses and answers with 304 if the
+// ETag matches If-None-Match.
+func conditionalGetHandler(cacheControl string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		original := c.Writer
+		writer := &bufferedResponseWriter{ResponseWriter: original}
+		c.Writer = writer
+		defer func() {
+			c.Writer = original
+		}()
+		c.Next()
+		if writer.streaming {
+			return
+		}
+		if original.Status() != http.StatusOK || len(c.Errors) > 0 {
+			// errors without body are written by gin_mw.ErrorHandler
+			if writer.body.Len() > 0 {
+				_, _ = original.Write(writer.body.Bytes())
+			}
+			return
+		}
+		header := original.Header()
+		etag := computeETag(writer.body.Bytes())
+		header.Set("ETag", etag)
+		if cacheControl != "" && header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", cacheControl)
+		}
+		if matchesETag(c.GetHeader("If-None-Match"), etag) {
+			header.Del("Content-Type")
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+		_, _ = original.Write(writer.body.Bytes())
+	}
+}
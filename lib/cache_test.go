@@ -0,0 +1,87 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConditionalGetHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(conditionalGetHandler("private, no-cache"))
+	router.GET("/dashboard", func(c *gin.Context) {
+		c.JSON(http.StatusOK, map[string]string{"name": "dashboard"})
+	})
+	router.GET("/public", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=60")
+		c.JSON(http.StatusOK, map[string]string{"name": "public"})
+	})
+	router.GET("/missing", func(c *gin.Context) {
+		abortWithError(c, ErrNotFound)
+	})
+	router.PUT("/dashboard", func(c *gin.Context) {
+		c.JSON(http.StatusOK, map[string]string{"name": "dashboard"})
+	})
+	etag := computeETag([]byte(`{"name":"dashboard"}`))
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		ifNoneMatch      string
+		wantCode         int
+		wantETag         string
+		wantCacheControl string
+		wantBody         bool
+	}{
+		{name: "without If-None-Match", method: http.MethodGet, path: "/dashboard", wantCode: http.StatusOK, wantETag: etag, wantCacheControl: "private, no-cache", wantBody: true},
+		{name: "matching ETag", method: http.MethodGet, path: "/dashboard", ifNoneMatch: etag, wantCode: http.StatusNotModified, wantETag: etag, wantCacheControl: "private, no-cache"},
+		{name: "matching weak ETag in a list", method: http.MethodGet, path: "/dashboard", ifNoneMatch: `"other", W/` + etag, wantCode: http.StatusNotModified, wantETag: etag, wantCacheControl: "private, no-cache"},
+		{name: "wildcard", method: http.MethodGet, path: "/dashboard", ifNoneMatch: "*", wantCode: http.StatusNotModified, wantETag: etag, wantCacheControl: "private, no-cache"},
+		{name: "changed ETag", method: http.MethodGet, path: "/dashboard", ifNoneMatch: `"other"`, wantCode: http.StatusOK, wantETag: etag, wantCacheControl: "private, no-cache", wantBody: true},
+		{name: "cache policy of the handler is kept", method: http.MethodGet, path: "/public", wantCode: http.StatusOK, wantETag: computeETag([]byte(`{"name":"public"}`)), wantCacheControl: "public, max-age=60", wantBody: true},
+		{name: "errors have no ETag", method: http.MethodGet, path: "/missing", ifNoneMatch: "*", wantCode: http.StatusNotFound, wantBody: true},
+		{name: "other methods have no ETag", method: http.MethodPut, path: "/dashboard", ifNoneMatch: etag, wantCode: http.StatusOK, wantBody: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			if test.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", test.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != test.wantCode {
+				t.Fatalf("got status %d, want %d", rec.Code, test.wantCode)
+			}
+			if got := rec.Header().Get("ETag"); got != test.wantETag {
+				t.Errorf("got ETag %q, want %q", got, test.wantETag)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != test.wantCacheControl {
+				t.Errorf("got Cache-Control %q, want %q", got, test.wantCacheControl)
+			}
+			if hasBody := rec.Body.Len() > 0; hasBody != test.wantBody {
+				t.Errorf("got body %q, want body %v", rec.Body.String(), test.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SyncConfig struct {
+	// TombstoneRetention is how long deletions are kept, 0 keeps them forever. Clients with older sync tokens get all
+	// dashboards.
+	TombstoneRetention time.Duration
+	// Overlap is subtracted from the sync token, so changes of transactions committed after the previous sync are not
+	// missed. It has to exceed the longest transaction, changes within the overlap are returned again.
+	Overlap time.Duration
+}
+
+// Tombstone marks a deleted dashboard, or a widget removed from a dashboard if WidgetId is set. Users that lose
+// access to a shared dashboard get a tombstone as well.
+type Tombstone struct {
+	DashboardId string    `json:"dashboard_id"`
+	WidgetId    string    `json:"widget_id,omitempty"`
+	DeletedAt   time.Time `json:"deleted_at"`
+	UserIds     []string  `json:"-"`
+}
+
+type DashboardChanges struct {
+	// Dashboards are created or updated since the token, in their current state.
+	Dashboards []Dashboard `json:"dashboards"`
+	// Deleted has to be applied before Dashboards.
+	Deleted []Tombstone `json:"deleted"`
+	// Reset is true if no or an expired token was given. Dashboards then contains all dashboards of the user and local
+	// dashboards missing in it have to be removed.
+	Reset bool `json:"reset"`
+	// Next is the token for the following request.
+	Next string `json:"next"`
+}
+
+func MongoTombstones() *mongo.Collection {
+	return DB.Database("dashboard").Collection("tombstones")
+}
+
+func newSyncToken(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixMilli(), 10)))
+}
+
+func parseSyncToken(token string) (time.Time, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, errors.Join(ErrBadRequest, errors.New("invalid sync token"))
+	}
+	millis, err := strconv.ParseInt(string(decoded), 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Join(ErrBadRequest, errors.New("invalid sync token"))
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// writeTombstones records the dashboards and widgets removed between before and after.
+func writeTombstones(ctx context.Context, before *Dashboard, after *Dashboard) error {
+	if before == nil {
+		return nil
+	}
+	now := time.Now()
+	tombstones := []interface{}{}
+	dashboardId := before.Id.Hex()
+	if after == nil {
+		tombstones = append(tombstones, Tombstone{DashboardId: dashboardId, DeletedAt: now, UserIds: dashboardUsers(*before)})
+	} else {
+		remaining := dashboardUsers(*after)
+		lostAccess := []string{}
+		for _, userId := range dashboardUsers(*before) {
+			if !slices.Contains(remaining, userId) {
+				lostAccess = append(lostAccess, userId)
+			}
+		}
+		if len(lostAccess) > 0 {
+			tombstones = append(tombstones, Tombstone{DashboardId: dashboardId, DeletedAt: now, UserIds: lostAccess})
+		}
+		for _, widget := range before.Widgets {
+			removed := !slices.ContainsFunc(after.Widgets, func(w Widget) bool {
+				return w.Id == widget.Id
+			})
+			if removed {
+				tombstones = append(tombstones, Tombstone{DashboardId: dashboardId, WidgetId: widget.Id.Hex(), DeletedAt: now, UserIds: remaining})
+			}
+		}
+	}
+	if len(tombstones) == 0 {
+		return nil
+	}
+	_, err := MongoTombstones().InsertMany(ctx, tombstones)
+	if err != nil {
+		log.Logger.Error("write tombstones failed", attributes.ErrorKey, err)
+		return errors.Join(ErrInternalServerError, err)
+	}
+	return nil
+}
+
+// latestDeletion returns when the user last lost a dashboard, the zero time if not within the tombstone retention.
+func latestDeletion(ctx context.Context, userId string) (time.Time, error) {
+	var tombstone Tombstone
+	err := MongoTombstones().FindOne(ctx, bson.M{"userids": userId, "widgetid": ""},
+		options.FindOne().SetSort(bson.D{{Key: "deletedat", Value: -1}})).Decode(&tombstone)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, normalizeModelError(err)
+	}
+	return tombstone.DeletedAt, nil
+}
+
+// getDashboardChanges returns the dashboards changed and removed since the token, all dashboards without token.
+func getDashboardChanges(ctx context.Context, token string, userId string) (result DashboardChanges, err error) {
+	defer observeMongo("getDashboardChanges", time.Now(), &err)
+	now := time.Now()
+	result = DashboardChanges{Dashboards: []Dashboard{}, Deleted: []Tombstone{}, Next: newSyncToken(now)}
+	var since time.Time
+	if token != "" {
+		since, err = parseSyncToken(token)
+		if err != nil {
+			return result, err
+		}
+		since = since.Add(-Config.Sync.Overlap)
+	}
+	result.Reset = token == "" || (Config.Sync.TombstoneRetention > 0 && since.Before(now.Add(-Config.Sync.TombstoneRetention)))
+
+	query := bson.M{"$or": bson.A{bson.M{"userid": userId}, bson.M{"shares.userid": userId}}}
+	if !result.Reset {
+		query["updatedAt"] = bson.M{"$gt": since}
+	}
+	cur, err := Mongo().Find(ctx, query, options.Find().SetSort(bson.D{{Key: "updatedAt", Value: 1}}))
+	if err != nil {
+		return result, normalizeModelError(err)
+	}
+	var dashs []Dashboard
+	if err = cur.All(ctx, &dashs); err != nil {
+		return result, normalizeModelError(err)
+	}
+	for _, dash := range dashs {
+		dash.Shared = dash.UserId != userId
+		result.Dashboards = append(result.Dashboards, dash.present(userId))
+	}
+	if result.Reset {
+		return result, nil
+	}
+
+	cur, err = MongoTombstones().Find(ctx, bson.M{"userids": userId, "deletedat": bson.M{"$gt": since}},
+		options.Find().SetSort(bson.D{{Key: "deletedat", Value: 1}}))
+	if err != nil {
+		return result, normalizeModelError(err)
+	}
+	if err = cur.All(ctx, &result.Deleted); err != nil {
+		return result, normalizeModelError(err)
+	}
+	return result, nil
+}
+
+func createTombstoneIndices(ctx context.Context) error {
+	_, err := MongoTombstones().Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "userids", Value: 1}, {Key: "deletedat", Value: 1}}})
+	if err != nil {
+		return err
+	}
+	return ensureTTLIndex(ctx, MongoTombstones(), "deletedat", Config.Sync.TombstoneRetention)
+}
@@ -0,0 +1,148 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import "time"
+
+type Configuration struct {
+	// AuthDevMode trusts the X-UserId and X-User-Roles headers instead of verifying JWTs. Never enable in production.
+	AuthDevMode  bool
+	JwksFile     string
+	JwtPublicKey string
+	JwtIssuer    string
+	JwtAudience  string
+	AdminRole    string
+	Quota        Quota
+	// WidgetSchemaDir contains one JSON Schema file per widget type, named <type>.json.
+	WidgetSchemaDir string
+	// WidgetTypeMode decides how widgets of types without schema are handled, see WidgetTypeModeStrict.
+	WidgetTypeMode string
+	Grid           GridConfig
+	Breakpoints    []Breakpoint
+	// EventHistorySize is the number of events kept to resume event streams with Last-Event-ID.
+	EventHistorySize       int
+	EventKeepAliveInterval time.Duration
+	Outbox                 OutboxConfig
+	// AuditRetention is how long audit entries are kept, 0 keeps them forever.
+	AuditRetention time.Duration
+	// MetricsAddress serves /metrics on a separate listener, e.g. ":9090". If empty, /metrics is served by the API server.
+	MetricsAddress string
+	// HealthTimeout bounds the database ping of the readiness check.
+	HealthTimeout time.Duration
+	Server        ServerConfig
+	Cors          CorsConfig
+	// HstsMaxAge sets the Strict-Transport-Security header, 0 disables it.
+	HstsMaxAge time.Duration
+	RateLimit  RateLimitConfig
+	// CacheControl is the Cache-Control header of GET responses. Responses carry an ETag, so clients can revalidate.
+	CacheControl CacheControlConfig
+	Sync         SyncConfig
+	// UndoStackSize is the number of changes kept per user and dashboard for undo, 0 disables undo.
+	UndoStackSize int
+}
+
+var Config Configuration
+
+// LoadConfig reads the configuration from the environment. Call after loading the .env file.
+func LoadConfig() {
+	Config = Configuration{
+		AuthDevMode:  GetEnv("AUTH_DEV_MODE", "false") == "true",
+		JwksFile:     GetEnv("JWT_JWKS_FILE", ""),
+		JwtPublicKey: GetEnv("JWT_PUBLIC_KEY", ""),
+		JwtIssuer:    GetEnv("JWT_ISSUER", ""),
+		JwtAudience:  GetEnv("JWT_AUDIENCE", ""),
+		AdminRole:    GetEnv("ADMIN_ROLE", "admin"),
+		Quota: Quota{
+			MaxDashboardsPerUser:   GetEnvInt("QUOTA_MAX_DASHBOARDS_PER_USER", 200),
+			MaxWidgetsPerDashboard: GetEnvInt("QUOTA_MAX_WIDGETS_PER_DASHBOARD", 200),
+			MaxPropertiesSize:      GetEnvInt("QUOTA_MAX_PROPERTIES_SIZE", 256*1024),
+			MaxPropertiesDepth:     GetEnvInt("QUOTA_MAX_PROPERTIES_DEPTH", 32),
+			MaxRequestBodySize:     GetEnvInt("QUOTA_MAX_REQUEST_BODY_SIZE", 4*1024*1024),
+			MaxBatchOperations:     GetEnvInt("QUOTA_MAX_BATCH_OPERATIONS", 100),
+		},
+		WidgetSchemaDir: GetEnv("WIDGET_SCHEMA_DIR", ""),
+		WidgetTypeMode:  GetEnv("WIDGET_TYPE_MODE", WidgetTypeModeOff),
+		Grid: GridConfig{
+			Columns:       GetEnvInt("GRID_COLUMNS", 12),
+			Rows:          GetEnvInt("GRID_ROWS", 1000),
+			MinWidth:      GetEnvInt("GRID_MIN_WIDTH", 1),
+			MaxWidth:      GetEnvInt("GRID_MAX_WIDTH", 0),
+			MinHeight:     GetEnvInt("GRID_MIN_HEIGHT", 1),
+			MaxHeight:     GetEnvInt("GRID_MAX_HEIGHT", 0),
+			DefaultWidth:  GetEnvInt("GRID_DEFAULT_WIDTH", 4),
+			DefaultHeight: GetEnvInt("GRID_DEFAULT_HEIGHT", 4),
+			CollisionMode: GetEnv("GRID_COLLISION_MODE", CollisionModeResolve),
+		},
+		Breakpoints:            parseBreakpoints(GetEnv("BREAKPOINTS", "lg:12,md:10,sm:6")),
+		EventHistorySize:       GetEnvInt("EVENT_HISTORY_SIZE", 1000),
+		EventKeepAliveInterval: time.Duration(GetEnvInt("EVENT_KEEP_ALIVE_SECONDS", 30)) * time.Second,
+		Outbox: OutboxConfig{
+			Sink:         GetEnv("OUTBOX_SINK", ""),
+			File:         GetEnv("OUTBOX_FILE", "outbox.jsonl"),
+			KafkaBrokers: splitList(GetEnv("OUTBOX_KAFKA_BROKERS", "")),
+			KafkaTopic:   GetEnv("OUTBOX_KAFKA_TOPIC", "dashboard-events"),
+			PollInterval: time.Duration(GetEnvInt("OUTBOX_POLL_INTERVAL_MS", 1000)) * time.Millisecond,
+			BatchSize:    GetEnvInt("OUTBOX_BATCH_SIZE", 100),
+			Retention:    time.Duration(GetEnvInt("OUTBOX_RETENTION_HOURS", 7*24)) * time.Hour,
+		},
+		AuditRetention: time.Duration(GetEnvInt("AUDIT_RETENTION_DAYS", 365)) * 24 * time.Hour,
+		MetricsAddress: GetEnv("METRICS_ADDRESS", ""),
+		HealthTimeout:  time.Duration(GetEnvInt("HEALTH_TIMEOUT_MS", 2000)) * time.Millisecond,
+		Server: ServerConfig{
+			ListenAddress:     GetEnv("LISTEN_ADDRESS", ":8080"),
+			ReadTimeout:       time.Duration(GetEnvInt("SERVER_READ_TIMEOUT_SECONDS", 30)) * time.Second,
+			ReadHeaderTimeout: time.Duration(GetEnvInt("SERVER_READ_HEADER_TIMEOUT_SECONDS", 10)) * time.Second,
+			WriteTimeout:      time.Duration(GetEnvInt("SERVER_WRITE_TIMEOUT_SECONDS", 60)) * time.Second,
+			IdleTimeout:       time.Duration(GetEnvInt("SERVER_IDLE_TIMEOUT_SECONDS", 120)) * time.Second,
+			ShutdownTimeout:   time.Duration(GetEnvInt("SERVER_SHUTDOWN_TIMEOUT_SECONDS", 30)) * time.Second,
+			TrustedProxies:    splitList(GetEnv("TRUSTED_PROXIES", "")),
+		},
+		Cors: CorsConfig{
+			AllowedOrigins:   splitList(GetEnv("CORS_ALLOWED_ORIGINS", "")),
+			AllowedMethods:   splitList(GetEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS")),
+			AllowedHeaders:   splitList(GetEnv("CORS_ALLOWED_HEADERS", "Authorization,Content-Type,If-None-Match,If-Modified-Since,Last-Event-ID,X-Request-ID")),
+			ExposedHeaders:   splitList(GetEnv("CORS_EXPOSED_HEADERS", "Last-Modified,ETag,X-Request-ID,Retry-After,X-RateLimit-Limit,X-RateLimit-Remaining,X-RateLimit-Reset")),
+			AllowCredentials: GetEnv("CORS_ALLOW_CREDENTIALS", "false") == "true",
+			MaxAge:           time.Duration(GetEnvInt("CORS_MAX_AGE_SECONDS", 600)) * time.Second,
+		},
+		HstsMaxAge: time.Duration(GetEnvInt("HSTS_MAX_AGE_SECONDS", 365*24*60*60)) * time.Second,
+		RateLimit: RateLimitConfig{
+			Store: GetEnv("RATE_LIMIT_STORE", RateLimitStoreMemory),
+			Read: RateLimit{
+				Rate:  float64(GetEnvInt("RATE_LIMIT_READ_PER_MINUTE", 600)) / 60,
+				Burst: GetEnvInt("RATE_LIMIT_READ_BURST", 120),
+			},
+			Write: RateLimit{
+				Rate:  float64(GetEnvInt("RATE_LIMIT_WRITE_PER_MINUTE", 120)) / 60,
+				Burst: GetEnvInt("RATE_LIMIT_WRITE_BURST", 30),
+			},
+		},
+		CacheControl: CacheControlConfig{
+			Private: GetEnv("CACHE_CONTROL_PRIVATE", "private, no-cache"),
+			Public:  GetEnv("CACHE_CONTROL_PUBLIC", "public, no-cache"),
+		},
+		Sync: SyncConfig{
+			TombstoneRetention: time.Duration(GetEnvInt("SYNC_TOMBSTONE_RETENTION_DAYS", 30)) * 24 * time.Hour,
+			Overlap:            time.Duration(GetEnvInt("SYNC_OVERLAP_SECONDS", 60)) * time.Second,
+		},
+		UndoStackSize: GetEnvInt("UNDO_STACK_SIZE", 50),
+	}
+	err := Config.Grid.validate()
+	if err != nil {
+		panic("invalid grid configuration: " + err.Error())
+	}
+}
@@ -21,6 +21,8 @@ package lib
 import (
 	"context"
 	"errors"
+	"regexp"
+	"slices"
 	"time"
 
 	"github.com/SENERGY-Platform/dashboard/lib/log"
@@ -35,7 +37,8 @@ func normalizeModelError(err error) error {
 	if err == nil {
 		return nil
 	}
-	if errors.Is(err, ErrBadRequest) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden) || errors.Is(err, ErrInternalServerError) {
+	if errors.Is(err, ErrBadRequest) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden) || errors.Is(err, ErrInternalServerError) ||
+		errors.Is(err, ErrPayloadTooLarge) || errors.Is(err, ErrUnprocessableEntity) || errors.Is(err, ErrConflict) {
 		return err
 	}
 	if errors.Is(err, primitive.ErrInvalidHex) {
@@ -47,29 +50,101 @@ func normalizeModelError(err error) error {
 	return errors.Join(ErrInternalServerError, err)
 }
 
+func readableDashboardFilter(id primitive.ObjectID, userId string) bson.M {
+	return bson.M{"_id": id, "$or": bson.A{
+		bson.M{"userid": userId},
+		bson.M{"shares.userid": userId},
+	}}
+}
+
+func writableDashboardFilter(id primitive.ObjectID, userId string) bson.M {
+	return bson.M{"_id": id, "$or": bson.A{
+		bson.M{"userid": userId},
+		bson.M{"shares": bson.M{"$elemMatch": bson.M{"userid": userId, "role": RoleEditor}}},
+	}}
+}
+
 func createDashboard(ctx context.Context, dash Dashboard, userId string) (result Dashboard, err error) {
+	defer observeMongo("createDashboard", time.Now(), &err)
+	err = validateShares(dash.Shares, userId)
+	if err != nil {
+		return result, err
+	}
+	err = checkDashboardWidgets(dash.Widgets)
+	if err != nil {
+		return result, err
+	}
+	err = dash.validateDashboardVariables()
+	if err != nil {
+		return result, err
+	}
+	err = validateWidgets(dash.Widgets, dash.Variables)
+	if err != nil {
+		return result, err
+	}
+	err = Config.Grid.arrangeWidgets(dash.Widgets)
+	if err != nil {
+		return result, err
+	}
+	for i := range dash.Widgets {
+		err = normalizeLayouts(&dash.Widgets[i])
+		if err != nil {
+			return result, err
+		}
+	}
+	if dash.FolderId == RootFolder {
+		dash.FolderId = ""
+	}
+	dash.FolderIndex = nil
 	dash.Id = primitive.NewObjectID()
 	dash.UserId = userId
-	dash.UpdatedAt = time.Now()
-	_, err = Mongo().InsertOne(ctx, dash)
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		err := checkDashboardQuota(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if dash.FolderId != "" {
+			_, err = getFolder(ctx, dash.FolderId, userId)
+			if err != nil {
+				return err
+			}
+		}
+		dash.UpdatedAt = time.Now()
+		_, err = Mongo().InsertOne(ctx, dash)
+		if err != nil {
+			log.Logger.Error("create dashboard failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		// new dashboards are appended to their folder, or to the dashboards outside of folders
+		err = renumberFolderDashboards(ctx, dash.FolderId, userId, dash.Id, -1)
+		if err != nil {
+			return err
+		}
+		_, result, err = getDashboard(nil, dash.Id.Hex(), userId, ctx)
+		if err != nil {
+			return err
+		}
+		return recordDashboardChange(ctx, EventDashboardCreated, nil, &result, "", userId)
+	})
 	if err != nil {
-		log.Logger.Error("create dashboard failed", attributes.ErrorKey, err)
-		return result, normalizeModelError(err)
+		return Dashboard{}, err
 	}
-	return dash, nil
+	return result, nil
 }
 
 func getDashboard(ifNotModifiedSince *time.Time, id string, userId string, ctx context.Context) (modified bool, dash Dashboard, err error) {
+	defer observeMongo("getDashboard", time.Now(), &err)
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return false, dash, normalizeModelError(err)
 	}
 
-	err = Mongo().FindOne(ctx, bson.M{"_id": objectId, "userid": userId}).Decode(&dash)
+	err = Mongo().FindOne(ctx, readableDashboardFilter(objectId, userId)).Decode(&dash)
 	if err != nil {
 		log.Logger.Error("find dashboard failed", attributes.ErrorKey, err)
 		return false, dash, normalizeModelError(err)
 	}
+	dash.Shared = dash.UserId != userId
 	modified = true
 	if ifNotModifiedSince != nil {
 		modified = dash.UpdatedAt.Truncate(time.Second).After(*ifNotModifiedSince)
@@ -77,71 +152,186 @@ func getDashboard(ifNotModifiedSince *time.Time, id string, userId string, ctx c
 	return
 }
 
-func getDashboards(ctx context.Context, ifNotModifiedSince *time.Time, userId string) (modified bool, dashs []Dashboard, err error) {
+func getResolvedDashboard(ctx context.Context, id string, values map[string][]string, userId string) (dash Dashboard, err error) {
+	_, dash, err = getDashboard(nil, id, userId, ctx)
+	if err != nil {
+		return dash, err
+	}
+	return dash.resolveVariables(values)
+}
+
+func getDashboardWithRole(ctx context.Context, id string, userId string, role string) (dash Dashboard, err error) {
+	defer observeMongo("getDashboardWithRole", time.Now(), &err)
+	_, dash, err = getDashboard(nil, id, userId, ctx)
+	if err != nil {
+		return dash, err
+	}
+	return dash, dash.checkAccess(userId, role)
+}
+
+// getDashboards returns the dashboards of the user. lastModified includes deletions, which are not visible in the
+// timestamps of the remaining dashboards. With createDefault, users without dashboards get the default dashboard.
+func getDashboards(ctx context.Context, filter DashboardFilter, createDefault bool, userId string) (lastModified time.Time, dashs []Dashboard, err error) {
+	defer observeMongo("getDashboards", time.Now(), &err)
+	query := bson.M{"$or": bson.A{bson.M{"userid": userId}, bson.M{"shares.userid": userId}}}
 	opts := options.Find().SetSort(bson.D{{Key: "index", Value: 1}})
-	cur, err := Mongo().Find(ctx, bson.M{"userid": userId}, opts)
+	if filter.Folder != "" {
+		query["folderid"] = folderFilter(filter.Folder)
+		opts.SetSort(bson.D{{Key: "folderindex", Value: 1}, {Key: "index", Value: 1}})
+	}
+	if len(filter.Tags) > 0 {
+		query["tags"] = bson.M{"$all": filter.Tags}
+	}
+	cur, err := Mongo().Find(ctx, query, opts)
 	if err != nil {
-		return false, nil, normalizeModelError(err)
+		return lastModified, nil, normalizeModelError(err)
 	}
-	if err = cur.All(ctx, &dashs); err != nil {
-		return false, nil, normalizeModelError(err)
+	var all []Dashboard
+	if err = cur.All(ctx, &all); err != nil {
+		return lastModified, nil, normalizeModelError(err)
 	}
 
-	if len(dashs) == 0 {
+	// own dashboards keep their index order, dashboards shared with the user are listed afterwards
+	shared := []Dashboard{}
+	for _, dash := range all {
+		if dash.UserId == userId {
+			dashs = append(dashs, dash)
+		} else {
+			dash.Shared = true
+			shared = append(shared, dash)
+		}
+	}
+
+	if len(dashs) == 0 && filter.isEmpty() && createDefault {
 		log.Logger.Info("user has no dashboards, creating default")
 		dash, err := createDefaultDashboard(ctx, userId)
 		if err != nil {
 			log.Logger.Error("create default dashboard failed", attributes.ErrorKey, err)
 		} else {
+			defaultDashboardsCreated.Inc()
 			dashs = append(dashs, dash)
 		}
 	}
-	modified = true
-	if ifNotModifiedSince != nil {
-		for _, dash := range dashs {
-			modified = dash.UpdatedAt.Truncate(time.Second).After(*ifNotModifiedSince)
-			if modified {
-				break
-			}
+	dashs = append(dashs, shared...)
+	lastModified, err = latestDeletion(ctx, userId)
+	if err != nil {
+		return lastModified, nil, err
+	}
+	if lastModified.IsZero() {
+		lastModified = time.Unix(0, 0)
+	}
+	for _, dash := range dashs {
+		if dash.UpdatedAt.After(lastModified) {
+			lastModified = dash.UpdatedAt
 		}
 	}
-	return
+	return lastModified.Truncate(time.Second), dashs, nil
 }
 
-func deleteDashboard(ctx context.Context, id string, userId string) (Response, error) {
-	var old Dashboard
-	objectId, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return Response{}, normalizeModelError(err)
-	}
+func deleteDashboard(ctx context.Context, id string, userId string) (result Response, err error) {
+	defer observeMongo("deleteDashboard", time.Now(), &err)
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		var old Dashboard
+		objectId, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return normalizeModelError(err)
+		}
 
-	err = Mongo().FindOne(ctx, bson.M{"_id": objectId, "userid": userId}).Decode(&old)
-	if err != nil {
-		log.Logger.Error("read dashboard before delete failed", attributes.ErrorKey, err)
-		return Response{}, normalizeModelError(err)
-	}
-	_, err = Mongo().DeleteOne(ctx, bson.M{"_id": objectId, "userid": userId})
+		err = Mongo().FindOne(ctx, readableDashboardFilter(objectId, userId)).Decode(&old)
+		if err != nil {
+			log.Logger.Error("read dashboard before delete failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		err = old.checkAccess(userId, RoleOwner)
+		if err != nil {
+			return err
+		}
+		_, err = Mongo().DeleteOne(ctx, bson.M{"_id": objectId, "userid": userId})
+		if err != nil {
+			log.Logger.Error("delete dashboard failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+
+		err = deleteShareLinksOfDashboard(ctx, objectId)
+		if err != nil {
+			return err
+		}
+		err = removeDashboardFromPreferences(ctx, id, nil)
+		if err != nil {
+			return err
+		}
+		if old.FolderIndex != nil {
+			err = renumberFolderDashboards(ctx, old.FolderId, userId, primitive.NilObjectID, 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		if old.Index != nil {
+			// update indices
+			info, err := Mongo().UpdateMany(ctx,
+				bson.M{"userid": userId, "index": bson.M{"$gte": *old.Index}}, bson.M{"$inc": bson.M{"index": -1}})
+			if err != nil {
+				log.Logger.Error("update dashboard indices after delete failed", attributes.ErrorKey, err)
+				return normalizeModelError(err)
+			}
+			log.Logger.Info("updated dashboard indices after delete", "modified_count", info.ModifiedCount)
+		} else {
+			log.Logger.Info("dashboard had no index, skipping update of other dashboards")
+		}
+		return recordDashboardChange(ctx, EventDashboardDeleted, &old, nil, "", userId)
+	})
 	if err != nil {
-		log.Logger.Error("delete dashboard failed", attributes.ErrorKey, err)
-		return Response{}, normalizeModelError(err)
+		return Response{}, err
 	}
+	return Response{"ok"}, nil
+}
 
-	if old.Index != nil {
-		// update indices
-		info, err := Mongo().UpdateMany(ctx,
-			bson.M{"userid": userId, "index": bson.M{"$gte": *old.Index}}, bson.M{"$inc": bson.M{"index": -1}})
+// editDashboard updates the metadata, tags and variables of a dashboard. Widgets, owner, shares and folder are kept.
+func editDashboard(ctx context.Context, dashboardId string, dashReq Dashboard, userId string) (result Dashboard, err error) {
+	defer observeMongo("editDashboard", time.Now(), &err)
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		oldDashboard, err := getDashboardWithRole(ctx, dashboardId, userId, RoleEditor)
+		if err != nil {
+			return err
+		}
+		dashReq.Widgets = oldDashboard.Widgets
+		dashReq.UserId = oldDashboard.UserId
+		dashReq.Shares = oldDashboard.Shares
+		dashReq.FolderId = oldDashboard.FolderId
+		dashReq.FolderIndex = oldDashboard.FolderIndex
+		// clients not aware of variables and tags keep them unchanged
+		if dashReq.Variables == nil {
+			dashReq.Variables = oldDashboard.Variables
+		}
+		if dashReq.Tags == nil {
+			dashReq.Tags = oldDashboard.Tags
+		}
+		if oldDashboard.Shared {
+			// the index orders the dashboards of the owner
+			dashReq.Index = oldDashboard.Index
+		}
+		err = dashReq.validateDashboardVariables()
 		if err != nil {
-			log.Logger.Error("update dashboard indices after delete failed", attributes.ErrorKey, err)
-			return Response{}, normalizeModelError(err)
+			return err
 		}
-		log.Logger.Info("updated dashboard indices after delete", "modified_count", info.ModifiedCount)
-	} else {
-		log.Logger.Info("dashboard had no index, skipping update of other dashboards")
+		dash, err := updateDashboard(dashReq, dashboardId, userId, ctx)
+		if err != nil {
+			return err
+		}
+		dash.Id = oldDashboard.Id
+		dash.Shared = oldDashboard.Shared
+		result = dash
+		return recordDashboardChange(ctx, EventDashboardUpdated, &oldDashboard, &dash, "", userId)
+	})
+	if err != nil {
+		return Dashboard{}, err
 	}
-	return Response{"ok"}, nil
+	return result, nil
 }
 
-func updateDashboard(newDashboard Dashboard, dashboardId string, userId string, ctx context.Context) (Dashboard, error) {
+func updateDashboard(newDashboard Dashboard, dashboardId string, userId string, ctx context.Context) (result Dashboard, err error) {
+	defer observeMongo("updateDashboard", time.Now(), &err)
 	newDashboard.UpdatedAt = time.Now()
 	update := bson.M{
 		"$set": newDashboard,
@@ -152,7 +342,7 @@ func updateDashboard(newDashboard Dashboard, dashboardId string, userId string,
 		return Dashboard{}, normalizeModelError(err)
 	}
 
-	_, err = Mongo().UpdateOne(ctx, bson.M{"_id": id, "userid": userId}, update)
+	_, err = Mongo().UpdateOne(ctx, writableDashboardFilter(id, userId), update)
 
 	if err != nil {
 		log.Logger.Error("update dashboard failed", attributes.ErrorKey, err)
@@ -161,13 +351,55 @@ func updateDashboard(newDashboard Dashboard, dashboardId string, userId string,
 	return newDashboard, nil
 }
 
+func updateDashboardShares(ctx context.Context, dashboardId string, shares []DashboardShare, userId string) (result []DashboardShare, err error) {
+	defer observeMongo("updateDashboardShares", time.Now(), &err)
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleOwner)
+		if err != nil {
+			return err
+		}
+		if shares == nil {
+			shares = []DashboardShare{}
+		}
+		err = validateShares(shares, dash.UserId)
+		if err != nil {
+			return err
+		}
+		_, err = Mongo().UpdateOne(ctx, bson.M{"_id": dash.Id, "userid": userId}, bson.M{"$set": bson.M{"shares": shares, "updatedAt": time.Now()}})
+		if err != nil {
+			log.Logger.Error("update dashboard shares failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		revoked := []string{}
+		for _, share := range dash.Shares {
+			if !slices.ContainsFunc(shares, func(s DashboardShare) bool { return s.UserId == share.UserId }) {
+				revoked = append(revoked, share.UserId)
+			}
+		}
+		if len(revoked) > 0 {
+			err = removeDashboardFromPreferences(ctx, dash.Id.Hex(), revoked)
+			if err != nil {
+				return err
+			}
+		}
+		after := dash.clone()
+		after.Shares = shares
+		return recordDashboardChange(ctx, EventDashboardUpdated, &dash, &after, "", userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
 func getWidget(ctx context.Context, ifNotModifiedSince *time.Time, dashboardId string, widgetId string, userId string) (modified bool, lastModified *time.Time, widget Widget, err error) {
+	defer observeMongo("getWidget", time.Now(), &err)
 	dash := Dashboard{}
 	objectID, err := primitive.ObjectIDFromHex(dashboardId)
 	if err != nil {
 		return false, nil, Widget{}, normalizeModelError(err)
 	}
-	err = Mongo().FindOne(ctx, bson.M{"_id": objectID, "userid": userId}).Decode(&dash)
+	err = Mongo().FindOne(ctx, readableDashboardFilter(objectID, userId)).Decode(&dash)
 	if err != nil {
 		log.Logger.Error("find dashboard for widget read failed", attributes.ErrorKey, err)
 		return false, nil, Widget{}, normalizeModelError(err)
@@ -179,6 +411,7 @@ func getWidget(ctx context.Context, ifNotModifiedSince *time.Time, dashboardId s
 		return false, nil, Widget{}, normalizeModelError(err)
 	}
 
+	dash = dash.withDerivedLayouts()
 	_, widget, err = dash.GetWidget(id)
 	if err != nil {
 		log.Logger.Error("get widget from dashboard failed", attributes.ErrorKey, err)
@@ -193,135 +426,365 @@ func getWidget(ctx context.Context, ifNotModifiedSince *time.Time, dashboardId s
 }
 
 func createWidget(ctx context.Context, dashboardId string, widget Widget, userId string) (result Widget, err error) {
-	_, dash, err := getDashboard(nil, dashboardId, userId, ctx)
+	defer observeMongo("createWidget", time.Now(), &err)
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleEditor)
+		if err != nil {
+			return err
+		}
+		before := dash.clone()
+		err = checkWidgetCount(len(dash.Widgets) + 1)
+		if err != nil {
+			return err
+		}
+		err = checkWidgetProperties(widget.Properties)
+		if err != nil {
+			return err
+		}
+		err = dash.checkVariableReferences(widget)
+		if err != nil {
+			return err
+		}
+		err = validateWidget(widget, dash.Variables)
+		if err != nil {
+			return err
+		}
+		err = normalizeLayouts(&widget)
+		if err != nil {
+			return err
+		}
+		widgetResult, err := dash.addWidget(widget)
+		if err != nil {
+			log.Logger.Error("create widget failed", attributes.ErrorKey, err)
+			return err
+		}
+		err = Config.Grid.arrangeWidget(dash.Widgets, len(dash.Widgets)-1)
+		if err != nil {
+			return err
+		}
+		widgetResult = dash.Widgets[len(dash.Widgets)-1]
+		_, err = updateDashboard(dash, dashboardId, userId, ctx)
+		if err != nil {
+			return err
+		}
+		result = widgetResult
+		return recordDashboardChange(ctx, EventWidgetAdded, &before, &dash, widgetResult.Id.Hex(), userId)
+	})
 	if err != nil {
 		return Widget{}, err
 	}
-	widgetResult, err := dash.addWidget(widget)
-	if err != nil {
-		log.Logger.Error("create widget failed", attributes.ErrorKey, err)
-		return result, err
-	}
-	_, err = updateDashboard(dash, dashboardId, userId, ctx)
-
-	return widgetResult, err
+	return result, nil
 }
 
 func updateWidget(ctx context.Context, dashboardId string, value interface{}, propertyToChange string, widgetID string, userId string) (err error) {
-	_, dash, err := getDashboard(nil, dashboardId, userId, ctx)
-	if err != nil {
-		return err
-	}
-	err = dash.updateWidget(value, propertyToChange, widgetID)
-	if err != nil {
-		log.Logger.Error("update widget failed", attributes.ErrorKey, err)
-		return err
-	}
-	dash, err = updateDashboard(dash, dashboardId, userId, ctx)
-
-	return err
-
+	defer observeMongo("updateWidget", time.Now(), &err)
+	return withTransaction(ctx, func(ctx context.Context) error {
+		dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleEditor)
+		if err != nil {
+			return err
+		}
+		before := dash.clone()
+		err = dash.updateWidget(value, propertyToChange, widgetID)
+		if err != nil {
+			log.Logger.Error("update widget failed", attributes.ErrorKey, err)
+			return err
+		}
+		if propertyToChange != "name" {
+			err = checkDashboardWidgets(dash.Widgets)
+			if err != nil {
+				return err
+			}
+			id, err := primitive.ObjectIDFromHex(widgetID)
+			if err != nil {
+				return normalizeModelError(err)
+			}
+			_, widget, err := dash.GetWidget(id)
+			if err != nil {
+				return err
+			}
+			err = dash.checkVariableReferences(widget)
+			if err != nil {
+				return err
+			}
+			err = validateWidget(widget, dash.Variables)
+			if err != nil {
+				return err
+			}
+		}
+		dash, err = updateDashboard(dash, dashboardId, userId, ctx)
+		if err != nil {
+			return err
+		}
+		return recordDashboardChange(ctx, EventWidgetChanged, &before, &dash, widgetID, userId)
+	})
 }
 
-func updateWidgetPositionInDashboard(positionUpdate WidgetPosition, userId string, ctx context.Context) (err error) {
-	dashboardId := positionUpdate.DashboardOrigin
-	_, dash, err := getDashboard(nil, dashboardId, userId, ctx)
-	if err != nil {
-		return err
-	}
-
-	i, _, err := dash.GetWidget(positionUpdate.Id)
-	if err != nil {
-		return err
-	}
+// updateWidgetPositionsInDashboard applies all position updates of one dashboard before handling collisions, so
+// widgets can swap places.
+func updateWidgetPositionsInDashboard(dashboardId string, positionUpdates []WidgetPosition, userId string, ctx context.Context) (err error) {
+	defer observeMongo("updateWidgetPositionsInDashboard", time.Now(), &err)
+	return withTransaction(ctx, func(ctx context.Context) error {
+		dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleEditor)
+		if err != nil {
+			return err
+		}
+		before := dash.clone()
 
-	dash.Widgets[i].X = positionUpdate.X
-	dash.Widgets[i].Y = positionUpdate.Y
-	dash.Widgets[i].W = positionUpdate.W
-	dash.Widgets[i].H = positionUpdate.H
+		moved := []int{}
+		breakpointPositions := map[string]map[int]WidgetPosition{}
+		for _, positionUpdate := range positionUpdates {
+			i, _, err := dash.GetWidget(positionUpdate.Id)
+			if err != nil {
+				return err
+			}
+			if positionUpdate.Breakpoint != "" {
+				if breakpointPositions[positionUpdate.Breakpoint] == nil {
+					breakpointPositions[positionUpdate.Breakpoint] = map[int]WidgetPosition{}
+				}
+				breakpointPositions[positionUpdate.Breakpoint][i] = positionUpdate
+				continue
+			}
+			dash.Widgets[i].X = positionUpdate.X
+			dash.Widgets[i].Y = positionUpdate.Y
+			dash.Widgets[i].W = positionUpdate.W
+			dash.Widgets[i].H = positionUpdate.H
+			if !slices.Contains(moved, i) {
+				moved = append(moved, i)
+			}
+		}
+		err = Config.Grid.arrangeMovedWidgets(dash.Widgets, moved)
+		if err != nil {
+			return err
+		}
+		for name, positions := range breakpointPositions {
+			bp, err := findBreakpoint(name)
+			if err != nil {
+				return err
+			}
+			err = setBreakpointLayouts(dash.Widgets, bp, positions)
+			if err != nil {
+				return err
+			}
+		}
 
-	dash, err = updateDashboard(dash, dashboardId, userId, ctx)
-	if err != nil {
-		log.Logger.Error("update dashboard after widget position swap failed", attributes.ErrorKey, err)
-		return err
-	}
-	return nil
+		dash, err = updateDashboard(dash, dashboardId, userId, ctx)
+		if err != nil {
+			log.Logger.Error("update dashboard after widget position swap failed", attributes.ErrorKey, err)
+			return err
+		}
+		widgetId := ""
+		if len(positionUpdates) == 1 {
+			widgetId = positionUpdates[0].Id.Hex()
+		}
+		return recordDashboardChange(ctx, EventWidgetMoved, &before, &dash, widgetId, userId)
+	})
 }
 
 func moveWidgetBetweenDashboards(positionUpdate WidgetPosition, userId string, ctx context.Context) (err error) {
-	_, oldDash, err := getDashboard(nil, positionUpdate.DashboardOrigin, userId, ctx)
-	if err != nil {
-		return err
-	}
-	_, newDash, err := getDashboard(nil, positionUpdate.DashboardDestination, userId, ctx)
-	if err != nil {
-		return err
-	}
+	defer observeMongo("moveWidgetBetweenDashboards", time.Now(), &err)
+	return withTransaction(ctx, func(ctx context.Context) error {
+		oldDash, err := getDashboardWithRole(ctx, positionUpdate.DashboardOrigin, userId, RoleEditor)
+		if err != nil {
+			return err
+		}
+		newDash, err := getDashboardWithRole(ctx, positionUpdate.DashboardDestination, userId, RoleEditor)
+		if err != nil {
+			return err
+		}
+		err = checkWidgetCount(len(newDash.Widgets) + 1)
+		if err != nil {
+			return err
+		}
 
-	oldPosition, widget, err := oldDash.GetWidget(positionUpdate.Id)
-	if err != nil {
-		return err
-	}
+		oldBefore, newBefore := oldDash.clone(), newDash.clone()
+		oldPosition, widget, err := oldDash.GetWidget(positionUpdate.Id)
+		if err != nil {
+			return err
+		}
+		err = newDash.checkVariableReferences(widget)
+		if err != nil {
+			return err
+		}
 
-	err = oldDash.removeWidgetAt(oldPosition)
-	if err != nil {
-		return err
-	}
-	_, err = updateDashboard(oldDash, positionUpdate.DashboardOrigin, userId, ctx)
-	if err != nil {
-		log.Logger.Error("update source dashboard after widget move failed", attributes.ErrorKey, err)
-		return err
-	}
+		err = oldDash.removeWidgetAt(oldPosition)
+		if err != nil {
+			return err
+		}
 
-	widget.X = positionUpdate.X
-	widget.Y = positionUpdate.Y
-	widget.W = positionUpdate.W
-	widget.H = positionUpdate.H
-	err = newDash.insertWidgetAt(len(newDash.Widgets), widget)
-	if err != nil {
-		return err
-	}
+		// breakpoint layouts refer to the grid of the source dashboard
+		widget.Layouts = nil
+		if positionUpdate.Breakpoint != "" {
+			// keep the size, the default layout is placed into the first free slot
+			widget.X, widget.Y = nil, nil
+		} else {
+			widget.X = positionUpdate.X
+			widget.Y = positionUpdate.Y
+			widget.W = positionUpdate.W
+			widget.H = positionUpdate.H
+		}
+		err = newDash.insertWidgetAt(len(newDash.Widgets), widget)
+		if err != nil {
+			return err
+		}
+		newIndex := len(newDash.Widgets) - 1
+		err = Config.Grid.arrangeWidget(newDash.Widgets, newIndex)
+		if err != nil {
+			return err
+		}
+		if positionUpdate.Breakpoint != "" {
+			bp, err := findBreakpoint(positionUpdate.Breakpoint)
+			if err != nil {
+				return err
+			}
+			err = setBreakpointLayouts(newDash.Widgets, bp, map[int]WidgetPosition{newIndex: positionUpdate})
+			if err != nil {
+				return err
+			}
+		}
 
-	_, err = updateDashboard(newDash, positionUpdate.DashboardDestination, userId, ctx)
-	if err != nil {
-		log.Logger.Error("update destination dashboard after widget move failed", attributes.ErrorKey, err)
-		return err
-	}
+		_, err = updateDashboard(oldDash, positionUpdate.DashboardOrigin, userId, ctx)
+		if err != nil {
+			log.Logger.Error("update source dashboard after widget move failed", attributes.ErrorKey, err)
+			return err
+		}
 
-	return nil
+		_, err = updateDashboard(newDash, positionUpdate.DashboardDestination, userId, ctx)
+		if err != nil {
+			log.Logger.Error("update destination dashboard after widget move failed", attributes.ErrorKey, err)
+			return err
+		}
+		// moves between dashboards are not undoable, the undo stacks are per dashboard
+		notUndoable := context.WithValue(ctx, undoContextKey{}, true)
+		err = recordDashboardChange(notUndoable, EventWidgetRemoved, &oldBefore, &oldDash, widget.Id.Hex(), userId)
+		if err != nil {
+			return err
+		}
+		err = recordDashboardChange(notUndoable, EventWidgetAdded, &newBefore, &newDash, widget.Id.Hex(), userId)
+		if err != nil {
+			return err
+		}
+		return dropWidgetUndo(ctx, widget.Id, positionUpdate.DashboardOrigin, positionUpdate.DashboardDestination)
+	})
 }
 
-func updateWidgetPositions(ctx context.Context, positionUpdates []WidgetPosition, userId string) (err error) {
-	for _, positionUpdate := range positionUpdates {
-		if positionUpdate.DashboardOrigin == positionUpdate.DashboardDestination {
-			err = updateWidgetPositionInDashboard(positionUpdate, userId, ctx)
+func compactDashboardLayout(ctx context.Context, dashboardId string, gravity string, dryRun bool, userId string) (result []WidgetLayout, err error) {
+	defer observeMongo("compactDashboardLayout", time.Now(), &err)
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		role := RoleEditor
+		if dryRun {
+			role = RoleViewer
+		}
+		dash, err := getDashboardWithRole(ctx, dashboardId, userId, role)
+		if err != nil {
+			return err
+		}
+		before := dash.clone()
+		err = Config.Grid.compact(dash.Widgets, gravity)
+		if err != nil {
+			return err
+		}
+		if !dryRun {
+			_, err = updateDashboard(dash, dashboardId, userId, ctx)
 			if err != nil {
+				log.Logger.Error("update dashboard after layout compaction failed", attributes.ErrorKey, err)
 				return err
 			}
-		} else {
-			err = moveWidgetBetweenDashboards(positionUpdate, userId, ctx)
+			err = recordDashboardChange(ctx, EventDashboardUpdated, &before, &dash, "", userId)
 			if err != nil {
 				return err
 			}
 		}
+		result = dash.layouts()
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	return err
+// updateWidgetPositions moves widgets between dashboards in the given order, then updates the positions within each
+// dashboard at once.
+func updateWidgetPositions(ctx context.Context, positionUpdates []WidgetPosition, userId string) (err error) {
+	return withTransaction(ctx, func(ctx context.Context) error {
+		dashboardIds := []string{}
+		inDashboard := map[string][]WidgetPosition{}
+		for _, positionUpdate := range positionUpdates {
+			if positionUpdate.DashboardOrigin != positionUpdate.DashboardDestination {
+				err := moveWidgetBetweenDashboards(positionUpdate, userId, ctx)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if _, ok := inDashboard[positionUpdate.DashboardOrigin]; !ok {
+				dashboardIds = append(dashboardIds, positionUpdate.DashboardOrigin)
+			}
+			inDashboard[positionUpdate.DashboardOrigin] = append(inDashboard[positionUpdate.DashboardOrigin], positionUpdate)
+		}
+		for _, dashboardId := range dashboardIds {
+			err := updateWidgetPositionsInDashboard(dashboardId, inDashboard[dashboardId], userId, ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func deleteWidget(ctx context.Context, dashboardId string, widgetId string, userId string) (err error) {
-	_, dash, err := getDashboard(nil, dashboardId, userId, ctx)
+	defer observeMongo("deleteWidget", time.Now(), &err)
+	return withTransaction(ctx, func(ctx context.Context) error {
+		dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleEditor)
+		if err != nil {
+			return err
+		}
+		before := dash.clone()
+		err = dash.deleteWidget(widgetId)
+		if err != nil {
+			log.Logger.Error("delete widget failed", attributes.ErrorKey, err)
+			return err
+		}
+		dash, err = updateDashboard(dash, dashboardId, userId, ctx)
+		if err != nil {
+			return err
+		}
+		return recordDashboardChange(ctx, EventWidgetRemoved, &before, &dash, widgetId, userId)
+	})
+}
+
+func searchWidgets(ctx context.Context, widgetType string, name string, userId string) (result []WidgetSearchResult, err error) {
+	defer observeMongo("searchWidgets", time.Now(), &err)
+	widgetFilter := bson.M{}
+	if widgetType != "" {
+		widgetFilter["widgets.type"] = widgetType
+	}
+	if name != "" {
+		widgetFilter["widgets.name"] = bson.M{"$regex": regexp.QuoteMeta(name), "$options": "i"}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userid": userId}}},
+		{{Key: "$unwind", Value: "$widgets"}},
+		{{Key: "$match", Value: widgetFilter}},
+		{{Key: "$sort", Value: bson.D{{Key: "index", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":            0,
+			"dashboard_id":   "$_id",
+			"dashboard_name": "$name",
+			"widget":         "$widgets",
+		}}},
+	}
+	cur, err := Mongo().Aggregate(ctx, pipeline)
 	if err != nil {
-		return
+		log.Logger.Error("search widgets failed", attributes.ErrorKey, err)
+		return nil, normalizeModelError(err)
 	}
-	err = dash.deleteWidget(widgetId)
-	if err != nil {
-		log.Logger.Error("delete widget failed", attributes.ErrorKey, err)
-		return err
+	result = []WidgetSearchResult{}
+	if err = cur.All(ctx, &result); err != nil {
+		log.Logger.Error("decode widget search result failed", attributes.ErrorKey, err)
+		return nil, normalizeModelError(err)
 	}
-	dash, err = updateDashboard(dash, dashboardId, userId, ctx)
-
-	return err
+	return result, nil
 }
 
 func migrateDashboardIndices() (err error) {
@@ -347,6 +810,7 @@ func migrateDashboardIndices() (err error) {
 			dash.Index = &userIndex
 			log.Logger.Info("adding dashboard index", "index", userIndex, "dashboard_id", dash.Id.Hex(), "user_id", dash.UserId)
 			updateDashboard(dash, dash.Id.Hex(), dash.UserId, context.TODO())
+			migratedDocuments.WithLabelValues("dashboard_index").Inc()
 		}
 		userIndex++
 	}
@@ -356,11 +820,16 @@ func migrateDashboardIndices() (err error) {
 func migrateUpdatedAt() (err error) {
 	log.Logger.Info("adding updatedAt to dashboards when needed")
 	ctx := context.TODO()
-	_, err = Mongo().UpdateMany(ctx, bson.M{"updatedAt": bson.M{"$exists": false}}, bson.M{"$currentDate": bson.M{"updatedAt": bson.M{"$type": "timestamp"}}})
-	return err
+	result, err := Mongo().UpdateMany(ctx, bson.M{"updatedAt": bson.M{"$exists": false}}, bson.M{"$currentDate": bson.M{"updatedAt": bson.M{"$type": "timestamp"}}})
+	if err != nil {
+		return err
+	}
+	migratedDocuments.WithLabelValues("updated_at").Add(float64(result.ModifiedCount))
+	return nil
 }
 
 func createDefaultDashboard(ctx context.Context, userId string) (result Dashboard, err error) {
+	defer observeMongo("createDefaultDashboard", time.Now(), &err)
 	result.Id = primitive.NewObjectID()
 	uZero := uint16(0)
 	result.UpdatedAt = time.Now()
@@ -434,10 +903,13 @@ func createDefaultDashboard(ctx context.Context, userId string) (result Dashboar
 		},
 	}
 
-	_, err = Mongo().InsertOne(ctx, result)
-	if err != nil {
-		log.Logger.Error("create default dashboard failed", attributes.ErrorKey, err)
-		return result, err
-	}
-	return result, nil
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		_, err := Mongo().InsertOne(ctx, result)
+		if err != nil {
+			log.Logger.Error("create default dashboard failed", attributes.ErrorKey, err)
+			return err
+		}
+		return recordDashboardChange(ctx, EventDashboardCreated, nil, &result, "", userId)
+	})
+	return result, err
 }
@@ -20,10 +20,12 @@ package lib
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"time"
 
 	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -48,6 +50,10 @@ func InitDB() {
 		log.Logger.Info("successfully connected to db")
 	}
 	DB = client
+	err = createIndices()
+	if err != nil {
+		panic("could not create database indices: " + err.Error())
+	}
 	err = migrateDashboardIndices()
 	if err != nil {
 		panic("could not migrate dashboard indices: " + err.Error())
@@ -56,6 +62,7 @@ func InitDB() {
 	if err != nil {
 		panic("could not migrate dashboard updatedAt: " + err.Error())
 	}
+	setMigrationsCompleted()
 }
 
 func Mongo() *mongo.Collection {
@@ -63,10 +70,169 @@ func Mongo() *mongo.Collection {
 
 }
 
+func MongoShareLinks() *mongo.Collection {
+	return DB.Database("dashboard").Collection("share_links")
+}
+
+func MongoFolders() *mongo.Collection {
+	return DB.Database("dashboard").Collection("folders")
+}
+
+func MongoPreferences() *mongo.Collection {
+	return DB.Database("dashboard").Collection("preferences")
+}
+
+func MongoOutbox() *mongo.Collection {
+	return DB.Database("dashboard").Collection("outbox")
+}
+
+func MongoOutboxSequences() *mongo.Collection {
+	return DB.Database("dashboard").Collection("outbox_sequences")
+}
+
+func MongoOutboxLeases() *mongo.Collection {
+	return DB.Database("dashboard").Collection("outbox_leases")
+}
+
+func MongoAudit() *mongo.Collection {
+	return DB.Database("dashboard").Collection("audit")
+}
+
+func createIndices() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := MongoShareLinks().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "dashboardid", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = MongoFolders().Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "userid", Value: 1}, {Key: "parentid", Value: 1}}})
+	if err != nil {
+		return err
+	}
+	_, err = MongoPreferences().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "homedashboardid", Value: 1}}},
+		{Keys: bson.D{{Key: "favoritedashboardids", Value: 1}}},
+		{Keys: bson.D{{Key: "lastopeneddashboardid", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = MongoOutbox().Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "publishedat", Value: 1}, {Key: "dashboardid", Value: 1}, {Key: "seq", Value: 1}}})
+	if err != nil {
+		return err
+	}
+	err = createTombstoneIndices(ctx)
+	if err != nil {
+		return err
+	}
+	err = createUndoIndices(ctx)
+	if err != nil {
+		return err
+	}
+	if Config.RateLimit.Store == RateLimitStoreMongo {
+		err = createRateLimitIndices(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return createAuditIndices(ctx)
+}
+
+// ensureTTLIndex removes documents once the time in field is older than retention. A retention of 0 keeps them
+// forever and drops the index. The index is updated if the retention changed.
+func ensureTTLIndex(ctx context.Context, collection *mongo.Collection, field string, retention time.Duration) error {
+	name := field + "_ttl"
+	var cmdErr mongo.CommandError
+	if retention <= 0 {
+		_, err := collection.Indexes().DropOne(ctx, name)
+		if errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+			return nil
+		}
+		return err
+	}
+	seconds := int32(retention.Seconds())
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetName(name).SetExpireAfterSeconds(seconds),
+	})
+	if errors.As(err, &cmdErr) && cmdErr.Name == "IndexOptionsConflict" {
+		return collection.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection.Name()},
+			{Key: "index", Value: bson.M{"name": name, "expireAfterSeconds": seconds}},
+		}).Err()
+	}
+	return err
+}
+
+type eventCollectorContextKey struct{}
+
+// eventCollector holds the events recorded in a transaction until it is committed.
+type eventCollector struct {
+	events []Event
+	// transient is the first transient error of a nested call, callers like batch operations may not return it.
+	transient error
+}
+
+// withTransaction runs fn in a transaction, fn may be retried on transient errors. Events recorded with
+// recordDashboardChange are published after the commit. Nested calls run in the transaction of the caller, their
+// transient errors retry the transaction even if the caller does not return them, like failed batch operations.
+func withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if collector, ok := ctx.Value(eventCollectorContextKey{}).(*eventCollector); ok {
+		err := fn(ctx)
+		if collector.transient == nil {
+			collector.transient = transientTransactionError(err)
+		}
+		return err
+	}
+	session, err := DB.StartSession()
+	if err != nil {
+		log.Logger.Error("start session failed", attributes.ErrorKey, err)
+		return normalizeModelError(err)
+	}
+	defer session.EndSession(ctx)
+	collector := &eventCollector{}
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		collector.events, collector.transient = nil, nil
+		err := fn(context.WithValue(sessionCtx, eventCollectorContextKey{}, collector))
+		if err == nil {
+			return nil, nil
+		}
+		if collector.transient != nil {
+			return nil, collector.transient
+		}
+		if transient := transientTransactionError(err); transient != nil {
+			return nil, transient
+		}
+		return nil, err
+	})
+	if err != nil {
+		return normalizeModelError(err)
+	}
+	for _, event := range collector.events {
+		events.publish(event)
+	}
+	return nil
+}
+
+// transientTransactionError returns the driver error labeled as transient contained in err, nil if there is none. The
+// driver only retries the transaction if it finds the label by Unwrap() error, which does not see through errors.Join.
+func transientTransactionError(err error) error {
+	var labeled mongo.LabeledError
+	if errors.As(err, &labeled) && labeled.HasErrorLabel("TransientTransactionError") {
+		return labeled
+	}
+	return nil
+}
+
 func CloseDB() {
 	ctx, cf := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cf()
 	if err := DB.Disconnect(ctx); err != nil {
-		panic(err)
+		log.Logger.Error("database disconnect failed", attributes.ErrorKey, err)
+		return
 	}
+	log.Logger.Info("disconnected from db")
 }
@@ -21,10 +21,14 @@ package lib
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	_ "github.com/SENERGY-Platform/dashboard/docs"
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"github.com/swaggo/swag"
 )
@@ -42,6 +46,34 @@ func getRootEndpoint(c *gin.Context) {
 	c.JSON(http.StatusOK, Response{"OK"})
 }
 
+// getLivenessEndpoint godoc
+// @Summary Liveness check
+// @Description Returns up while the process is able to handle requests. Does not check the database.
+// @Tags status
+// @Produce json
+// @Success 200 {object} HealthStatus
+// @Router /health/live [get]
+func getLivenessEndpoint(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthStatus{Status: HealthUp})
+}
+
+// getReadinessEndpoint godoc
+// @Summary Readiness check
+// @Description Checks the database connection and the migration status. Returns 503 if any check is down.
+// @Tags status
+// @Produce json
+// @Success 200 {object} HealthStatus
+// @Failure 503 {object} HealthStatus
+// @Router /health/ready [get]
+func getReadinessEndpoint(c *gin.Context) {
+	result := getReadiness(c.Request.Context())
+	status := http.StatusOK
+	if result.Status != HealthUp {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, result)
+}
+
 // swaggerDocHandler godoc
 // @Summary Get OpenAPI document
 // @Description Returns the generated Swagger document for this service.
@@ -70,12 +102,16 @@ func swaggerDocHandler(c *gin.Context) {
 // @Param dashboard body Dashboard true "Dashboard payload"
 // @Success 200 {object} Dashboard
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /dashboards [post]
 func createDashboardEndpoint(c *gin.Context) {
 	var dashReq Dashboard
 	if err := c.ShouldBind(&dashReq); err != nil {
-		_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("Could not decode Dashboard Request data"), err))
+		_ = c.Error(errors.Join(bindError(err), errors.New("Could not decode Dashboard Request data"), err))
 		return
 	}
 	result, err := createDashboard(c.Request.Context(), dashReq, getUserId(c))
@@ -83,7 +119,7 @@ func createDashboardEndpoint(c *gin.Context) {
 		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while creating dashboard"), err))
 		return
 	}
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, result.present(getUserId(c)))
 }
 
 // getDashboardEndpoint godoc
@@ -96,6 +132,7 @@ func createDashboardEndpoint(c *gin.Context) {
 // @Success 304 {string} string
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /dashboards/{id} [get]
 func getDashboardEndpoint(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -109,50 +146,107 @@ func getDashboardEndpoint(c *gin.Context) {
 		c.Status(http.StatusNotModified)
 		return
 	}
-	addCacheControlHeaders(c, dashboard.UpdatedAt)
-	c.JSON(http.StatusOK, dashboard)
+	addLastModifiedHeader(c, dashboard.UpdatedAt)
+	c.JSON(http.StatusOK, dashboard.present(getUserId(c)))
+}
+
+// getResolvedDashboardEndpoint godoc
+// @Summary Get dashboard with resolved variables
+// @Description Returns a dashboard where all variable references ("${name}") in widget properties are replaced.
+// @Description Values are passed as query parameters named var-<name>, variables without value use their default.
+// @Description Time ranges are given as "from,to", string lists as repeated or comma separated values.
+// @Tags dashboards
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Success 200 {object} Dashboard
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/resolved [get]
+func getResolvedDashboardEndpoint(c *gin.Context) {
+	values := map[string][]string{}
+	for key, value := range c.Request.URL.Query() {
+		if name, found := strings.CutPrefix(key, "var-"); found {
+			values[name] = value
+		}
+	}
+	dashboard, err := getResolvedDashboard(c.Request.Context(), c.Param("id"), values, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while resolving dashboard"), err))
+		return
+	}
+	c.JSON(http.StatusOK, dashboard.present(getUserId(c)))
 }
 
 // getDashboardsEndpoint godoc
 // @Summary List dashboards
-// @Description Returns all dashboards for the current user.
+// @Description Returns all dashboards of the current user, followed by the dashboards shared with the current user.
+// @Description Without filter the dashboards are ordered by index, with a folder filter by their position inside the folder.
 // @Tags dashboards
 // @Produce json
+// @Param folder query string false "Folder ID, or root for dashboards outside of folders"
+// @Param tag query []string false "Tags, all have to match" collectionFormat(multi)
 // @Success 200 {array} Dashboard
 // @Success 304 {string} string
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /dashboards [get]
 func getDashboardsEndpoint(c *gin.Context) {
 	t := parseModifiedSince(c)
-	modified, dashboards, err := getDashboards(c.Request.Context(), t, getUserId(c))
+	filter := DashboardFilter{Folder: c.Query("folder"), Tags: c.QueryArray("tag")}
+	// admins inspecting a user must not create dashboards
+	lastModified, dashboards, err := getDashboards(c.Request.Context(), filter, !isImpersonated(c), getUserId(c))
 	if err != nil {
 		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading dashboards"), err))
 		return
 	}
-	if t != nil && !modified {
+	if t != nil && !lastModified.After(*t) {
 		c.Status(http.StatusNotModified)
 		return
 	}
-	latest := time.Unix(0, 0)
-	for _, dash := range dashboards {
-		if dash.UpdatedAt.After(latest) {
-			latest = dash.UpdatedAt
-		}
+	userId := getUserId(c)
+	for i, dash := range dashboards {
+		dashboards[i] = dash.present(userId)
 	}
-	latest = latest.Truncate(time.Second)
-	addCacheControlHeaders(c, latest)
+	addLastModifiedHeader(c, lastModified)
 	c.JSON(http.StatusOK, &dashboards)
 }
 
+// getDashboardChangesEndpoint godoc
+// @Summary List dashboard changes
+// @Description Returns the dashboards created or updated and the dashboards and widgets deleted since the sync token,
+// @Description including dashboards shared with the current user. Without token, or if the token expired, all
+// @Description dashboards are returned with reset set. Changes may be returned more than once. Clients apply the
+// @Description deletions first, then the dashboards, and pass next as since in the following request.
+// @Tags dashboards
+// @Produce json
+// @Param since query string false "Sync token of the previous response"
+// @Success 200 {object} DashboardChanges
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/changes [get]
+func getDashboardChangesEndpoint(c *gin.Context) {
+	changes, err := getDashboardChanges(c.Request.Context(), c.Query("since"), getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading dashboard changes"), err))
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
 // deleteDashboardEndpoint godoc
 // @Summary Delete dashboard
-// @Description Deletes a dashboard by id.
+// @Description Deletes a dashboard by id. Only the owner may delete a dashboard.
 // @Tags dashboards
 // @Produce json
 // @Param id path string true "Dashboard ID"
 // @Success 200 {object} Response
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /dashboards/{id} [delete]
 func deleteDashboardEndpoint(c *gin.Context) {
 	result, err := deleteDashboard(c.Request.Context(), c.Param("id"), getUserId(c))
@@ -165,7 +259,7 @@ func deleteDashboardEndpoint(c *gin.Context) {
 
 // editDashboardEndpoint godoc
 // @Summary Update dashboard
-// @Description Updates dashboard metadata by id.
+// @Description Updates dashboard metadata, tags and variables by id. Owner, shares and folder are kept unchanged.
 // @Tags dashboards
 // @Accept json
 // @Produce json
@@ -173,33 +267,351 @@ func deleteDashboardEndpoint(c *gin.Context) {
 // @Param dashboard body Dashboard true "Dashboard payload"
 // @Success 200 {object} Dashboard
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /dashboards/{id} [put]
 func editDashboardEndpoint(c *gin.Context) {
 	var dashReq Dashboard
 	if err := c.ShouldBind(&dashReq); err != nil {
-		_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("Error while decoding dashboard"), err))
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while decoding dashboard"), err))
 		return
 	}
 
-	dashboardId := c.Param("id")
 	userId := getUserId(c)
-	ctx := c.Request.Context()
-	_, oldDashboard, err := getDashboard(nil, dashboardId, userId, ctx)
+	dash, err := editDashboard(c.Request.Context(), c.Param("id"), dashReq, userId)
 	if err != nil {
-		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading dashboard"), err))
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while updating dashboard"), err))
 		return
 	}
-	dashReq.Widgets = oldDashboard.Widgets
 
-	dash, err := updateDashboard(dashReq, dashboardId, userId, ctx)
+	c.JSON(http.StatusOK, dash.present(userId))
+}
+
+// getDashboardSharesEndpoint godoc
+// @Summary List dashboard shares
+// @Description Returns the users a dashboard is shared with. Only the owner may read the shares.
+// @Tags dashboards
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Success 200 {array} DashboardShare
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/shares [get]
+func getDashboardSharesEndpoint(c *gin.Context) {
+	dash, err := getDashboardWithRole(c.Request.Context(), c.Param("id"), getUserId(c), RoleOwner)
 	if err != nil {
-		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while updating dashboard"), err))
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading dashboard shares"), err))
+		return
+	}
+	shares := dash.Shares
+	if shares == nil {
+		shares = []DashboardShare{}
+	}
+	c.JSON(http.StatusOK, shares)
+}
+
+// updateDashboardSharesEndpoint godoc
+// @Summary Update dashboard shares
+// @Description Replaces the users a dashboard is shared with. Viewers may only read the dashboard, editors may also change its widgets. Only the owner may change the shares.
+// @Description Users losing their share no longer have the dashboard as home, last opened or favorite dashboard.
+// @Tags dashboards
+// @Accept json
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Param shares body []DashboardShare true "Complete list of shares"
+// @Success 200 {array} DashboardShare
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/shares [put]
+func updateDashboardSharesEndpoint(c *gin.Context) {
+	var shares []DashboardShare
+	if err := c.ShouldBind(&shares); err != nil {
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while decoding dashboard shares"), err))
+		return
+	}
+	result, err := updateDashboardShares(c.Request.Context(), c.Param("id"), shares, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while updating dashboard shares"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// createShareLinkEndpoint godoc
+// @Summary Create public share link
+// @Description Creates a random, revocable token granting read-only access to a dashboard without authentication. Only the owner may create links.
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Param link body ShareLinkRequest false "Optional link expiry"
+// @Success 200 {object} ShareLink
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/links [post]
+func createShareLinkEndpoint(c *gin.Context) {
+	var req ShareLinkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(errors.Join(bindError(err), errors.New("Error while decoding share link request"), err))
+			return
+		}
+	}
+	result, err := createShareLink(c.Request.Context(), c.Param("id"), req, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while creating share link"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// listShareLinksEndpoint godoc
+// @Summary List public share links
+// @Description Returns all public share links of a dashboard, including expired ones. Only the owner may list links.
+// @Tags share-links
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Success 200 {array} ShareLink
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/links [get]
+func listShareLinksEndpoint(c *gin.Context) {
+	result, err := listShareLinks(c.Request.Context(), c.Param("id"), getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading share links"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// deleteShareLinkEndpoint godoc
+// @Summary Revoke public share link
+// @Description Deletes a public share link. Only the owner may revoke links.
+// @Tags share-links
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Param linkId path string true "Link ID"
+// @Success 200 {object} Response
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/links/{linkId} [delete]
+func deleteShareLinkEndpoint(c *gin.Context) {
+	err := deleteShareLink(c.Request.Context(), c.Param("id"), c.Param("linkId"), getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while deleting share link"), err))
+		return
+	}
+	c.JSON(http.StatusOK, Response{"OK"})
+}
+
+// getPublicDashboardEndpoint godoc
+// @Summary Get shared dashboard
+// @Description Returns the dashboard of a public share link read-only. Does not require authentication. User ids are removed.
+// @Tags share-links
+// @Produce json
+// @Param token path string true "Share link token"
+// @Success 200 {object} Dashboard
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /public/dashboards/{token} [get]
+func getPublicDashboardEndpoint(c *gin.Context) {
+	dashboard, err := getPublicDashboard(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading shared dashboard"), err))
+		return
+	}
+	addLastModifiedHeader(c, dashboard.UpdatedAt)
+	c.JSON(http.StatusOK, dashboard.withDerivedLayouts())
+}
+
+// compactDashboardLayoutEndpoint godoc
+// @Summary Compact dashboard layout
+// @Description Moves all widgets of a dashboard up (vertical gravity) or left (horizontal gravity) to close gaps.
+// @Description With dry_run the proposed positions are returned without saving them.
+// @Tags dashboards
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Param gravity query string false "Compaction direction" Enums(vertical, horizontal) default(vertical)
+// @Param dry_run query bool false "Only return the proposed positions"
+// @Success 200 {array} WidgetLayout
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/layout/compact [post]
+func compactDashboardLayoutEndpoint(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("Could not parse dry_run"), err))
+		return
+	}
+	result, err := compactDashboardLayout(c.Request.Context(), c.Param("id"), c.DefaultQuery("gravity", GravityVertical), dryRun, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while compacting dashboard layout"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// moveDashboardToFolderEndpoint godoc
+// @Summary Move dashboard to folder
+// @Description Moves a dashboard into a folder at the given position, or at the end without index. Use root as folder_id to move it out of all folders. Only the owner may move a dashboard.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Param move body DashboardFolderMove true "Target folder and position"
+// @Success 200 {object} Response
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/folder [put]
+func moveDashboardToFolderEndpoint(c *gin.Context) {
+	var move DashboardFolderMove
+	if err := c.ShouldBindJSON(&move); err != nil {
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while decoding folder move"), err))
+		return
+	}
+	err := moveDashboardToFolder(c.Request.Context(), c.Param("id"), move, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while moving dashboard to folder"), err))
+		return
+	}
+	c.JSON(http.StatusOK, Response{"OK"})
+}
+
+// listFoldersEndpoint godoc
+// @Summary List folders
+// @Description Returns all folders of the current user. Folders without parent_id are top level folders.
+// @Tags folders
+// @Produce json
+// @Success 200 {array} Folder
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /folders [get]
+func listFoldersEndpoint(c *gin.Context) {
+	result, err := listFolders(c.Request.Context(), getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading folders"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// getFolderEndpoint godoc
+// @Summary Get folder
+// @Description Returns a folder by id.
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID"
+// @Success 200 {object} Folder
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /folders/{id} [get]
+func getFolderEndpoint(c *gin.Context) {
+	result, err := getFolder(c.Request.Context(), c.Param("id"), getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading folder"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// createFolderEndpoint godoc
+// @Summary Create folder
+// @Description Creates a folder, optionally inside a parent folder. The index is the position among the sibling
+// @Description folders, the indices of the siblings are renumbered.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param folder body Folder true "Folder payload"
+// @Success 200 {object} Folder
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /folders [post]
+func createFolderEndpoint(c *gin.Context) {
+	var folder Folder
+	if err := c.ShouldBindJSON(&folder); err != nil {
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while decoding folder"), err))
+		return
+	}
+	result, err := createFolder(c.Request.Context(), folder, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while creating folder"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// updateFolderEndpoint godoc
+// @Summary Update folder
+// @Description Renames, reorders or moves a folder. A folder can not be moved into itself or its sub folders. The
+// @Description index is the position among the sibling folders, the indices of the siblings are renumbered.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID"
+// @Param folder body Folder true "Folder payload"
+// @Success 200 {object} Folder
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /folders/{id} [put]
+func updateFolderEndpoint(c *gin.Context) {
+	var folder Folder
+	if err := c.ShouldBindJSON(&folder); err != nil {
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while decoding folder"), err))
+		return
+	}
+	result, err := updateFolder(c.Request.Context(), c.Param("id"), folder, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while updating folder"), err))
 		return
 	}
+	c.JSON(http.StatusOK, result)
+}
 
-	c.JSON(http.StatusOK, dash)
+// deleteFolderEndpoint godoc
+// @Summary Delete folder
+// @Description Deletes a folder. Its sub folders and dashboards are moved to the parent folder.
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /folders/{id} [delete]
+func deleteFolderEndpoint(c *gin.Context) {
+	err := deleteFolder(c.Request.Context(), c.Param("id"), getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while deleting folder"), err))
+		return
+	}
+	c.JSON(http.StatusOK, Response{"OK"})
 }
 
 // getWidgetEndpoint godoc
@@ -213,6 +625,7 @@ func editDashboardEndpoint(c *gin.Context) {
 // @Success 304 {string} string
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /widgets/{dashboardId}/{widgetId} [get]
 func getWidgetEndpoint(c *gin.Context) {
 	t := parseModifiedSince(c)
@@ -225,10 +638,30 @@ func getWidgetEndpoint(c *gin.Context) {
 		c.Status(http.StatusNotModified)
 		return
 	}
-	addCacheControlHeaders(c, *lastModified)
+	addLastModifiedHeader(c, *lastModified)
 	c.JSON(http.StatusOK, widget)
 }
 
+// searchWidgetsEndpoint godoc
+// @Summary Search widgets
+// @Description Returns all widgets of the current user matching the given type and name, together with their dashboard.
+// @Tags widgets
+// @Produce json
+// @Param type query string false "Widget type (exact match)"
+// @Param name query string false "Widget name (case-insensitive substring match)"
+// @Success 200 {array} WidgetSearchResult
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /widgets [get]
+func searchWidgetsEndpoint(c *gin.Context) {
+	result, err := searchWidgets(c.Request.Context(), c.Query("type"), c.Query("name"), getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while searching widgets"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // editSingleWidgetPropertyEndpoint godoc
 // @Summary Update one widget property
 // @Description Updates a single widget property by property key.
@@ -241,14 +674,18 @@ func getWidgetEndpoint(c *gin.Context) {
 // @Param value body object true "New property value"
 // @Success 200 {object} Response
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /widgets/properties/{property}/{dashboardId}/{widgetId} [patch]
 func editSingleWidgetPropertyEndpoint(c *gin.Context) {
 	var newValue interface{}
 	err := c.ShouldBind(&newValue)
 	if err != nil {
-		_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("Error while reading request body"), err))
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while reading request body"), err))
 		return
 	}
 
@@ -297,14 +734,18 @@ func editWidgetPropertiesDispatchEndpoint(c *gin.Context) {
 // @Param properties body object true "New properties object"
 // @Success 200 {object} Response
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /widgets/properties/{dashboardId}/{widgetId} [patch]
 func editWidgetPropertyEndpoint(c *gin.Context) {
 	var newValue interface{}
 	err := c.ShouldBind(&newValue)
 	if err != nil {
-		_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("Error while reading request body"), err))
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while reading request body"), err))
 		return
 	}
 
@@ -327,14 +768,16 @@ func editWidgetPropertyEndpoint(c *gin.Context) {
 // @Param name body string true "New widget name"
 // @Success 200 {object} Response
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /widgets/name/{dashboardId}/{widgetId} [patch]
 func editWidgetNameEndpoint(c *gin.Context) {
 	var name string
 	err := c.ShouldBind(&name)
 	if err != nil {
-		_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("Error while reading request body"), err))
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while reading request body"), err))
 		return
 	}
 	err = updateWidget(c.Request.Context(), c.Param("dashboardId"), name, "name", c.Param("widgetId"), getUserId(c))
@@ -347,19 +790,25 @@ func editWidgetNameEndpoint(c *gin.Context) {
 
 // editWidgetPosition godoc
 // @Summary Update widget positions
-// @Description Updates positions for multiple widgets.
+// @Description Updates positions for multiple widgets. If a breakpoint is given, the layout of the widget for that breakpoint is updated instead of the default layout. Positions are validated against the grid, widgets without coordinates are placed into the first free slot.
+// @Description Collisions are checked after all positions of a dashboard are applied, so widgets can swap places. Overlapping widgets are pushed down or rejected with 409, depending on the configured collision mode.
 // @Tags widgets
 // @Accept json
 // @Produce json
 // @Param positions body []WidgetPosition true "Widget position updates"
 // @Success 200 {object} Response
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /widgets/positions [patch]
 func editWidgetPosition(c *gin.Context) {
 	var widgetReq []WidgetPosition
 	if err := c.ShouldBind(&widgetReq); err != nil {
-		_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("Could not decode Widget Position Request data"), err))
+		_ = c.Error(errors.Join(bindError(err), errors.New("Could not decode Widget Position Request data"), err))
 		return
 	}
 
@@ -373,7 +822,7 @@ func editWidgetPosition(c *gin.Context) {
 
 // createWidgetEndpoint godoc
 // @Summary Create widget
-// @Description Creates a new widget in a dashboard.
+// @Description Creates a new widget in a dashboard. Widgets without coordinates are placed into the first free slot of the grid.
 // @Tags widgets
 // @Accept json
 // @Produce json
@@ -381,13 +830,18 @@ func editWidgetPosition(c *gin.Context) {
 // @Param widget body Widget true "Widget payload"
 // @Success 200 {object} Widget
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /widgets/{dashboardId} [post]
 func createWidgetEndpoint(c *gin.Context) {
 	var widgetReq Widget
 	if err := c.ShouldBind(&widgetReq); err != nil {
-		_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("Error while decoding widget data"), err))
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while decoding widget data"), err))
 		return
 	}
 
@@ -407,8 +861,10 @@ func createWidgetEndpoint(c *gin.Context) {
 // @Param dashboardId path string true "Dashboard ID"
 // @Param widgetId path string true "Widget ID"
 // @Success 200 {object} Response
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Security Bearer
 // @Router /widgets/{dashboardId}/{widgetId} [delete]
 func deleteWidgetEndpoint(c *gin.Context) {
 	err := deleteWidget(c.Request.Context(), c.Param("dashboardId"), c.Param("widgetId"), getUserId(c))
@@ -418,3 +874,323 @@ func deleteWidgetEndpoint(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, Response{"OK"})
 }
+
+// listUsersEndpoint godoc
+// @Summary List users
+// @Description Returns all users owning dashboards with their dashboard count. Requires the admin role.
+// @Description All dashboard and widget routes are also available below /admin/users/{userId} to act on behalf of that user.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} UserSummary
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /admin/users [get]
+func listUsersEndpoint(c *gin.Context) {
+	result, err := listUsers(c.Request.Context())
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while listing users"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// resetUserEndpoint godoc
+// @Summary Reset user
+// @Description Deletes all dashboards of a user and creates the default dashboard. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} Dashboard
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /admin/users/{userId}/reset [post]
+func resetUserEndpoint(c *gin.Context) {
+	result, err := resetUserDashboards(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while resetting user"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// listAuditEndpoint godoc
+// @Summary List audit entries
+// @Description Returns the audit entries of all writes, newest first. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Param dashboard query string false "Dashboard ID"
+// @Param user query string false "ID of the acting user"
+// @Param from query string false "Earliest time, RFC 3339"
+// @Param to query string false "Latest time (exclusive), RFC 3339"
+// @Param limit query int false "Maximum number of entries, at most 1000" default(100)
+// @Success 200 {array} AuditEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /audit [get]
+func listAuditEndpoint(c *gin.Context) {
+	query := AuditQuery{DashboardId: c.Query("dashboard"), ActorId: c.Query("user")}
+	for param, target := range map[string]**time.Time{"from": &query.From, "to": &query.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("Could not parse "+param), err))
+			return
+		}
+		*target = &t
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	if err != nil || limit <= 0 || limit > 1000 {
+		_ = c.Error(errors.Join(GetError(http.StatusBadRequest), errors.New("limit has to be between 1 and 1000"), err))
+		return
+	}
+	query.Limit = limit
+	result, err := listAuditEntries(c.Request.Context(), query)
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading audit entries"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// getQuotaEndpoint godoc
+// @Summary Get quota
+// @Description Returns the configured limits and the current usage of the user. A limit of 0 is unlimited.
+// @Tags quota
+// @Produce json
+// @Success 200 {object} QuotaStatus
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /quota [get]
+func getQuotaEndpoint(c *gin.Context) {
+	result, err := getQuotaStatus(c.Request.Context(), getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading quota"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// getPreferencesEndpoint godoc
+// @Summary Get preferences
+// @Description Returns the preferences of the user. Dashboards deleted since are removed automatically.
+// @Tags preferences
+// @Produce json
+// @Success 200 {object} Preferences
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /preferences [get]
+func getPreferencesEndpoint(c *gin.Context) {
+	result, err := getPreferences(c.Request.Context(), getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while reading preferences"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// updatePreferencesEndpoint godoc
+// @Summary Update preferences
+// @Description Replaces the preferences of the user. All referenced dashboards have to be readable by the user.
+// @Tags preferences
+// @Accept json
+// @Produce json
+// @Param preferences body Preferences true "Preferences payload"
+// @Success 200 {object} Preferences
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /preferences [put]
+func updatePreferencesEndpoint(c *gin.Context) {
+	var prefs Preferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		_ = c.Error(errors.Join(bindError(err), errors.New("Error while decoding preferences"), err))
+		return
+	}
+	result, err := updatePreferences(c.Request.Context(), prefs, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while updating preferences"), err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// getEventsEndpoint godoc
+// @Summary Stream change events
+// @Description Streams Server-Sent Events for changes of the dashboards of the user and the dashboards shared with the user.
+// @Description The event name is the event type, the data the event as JSON. Reconnecting clients send the id of the last
+// @Description received event as Last-Event-ID header or last_event_id query parameter to receive the missed events.
+// @Description If these are no longer available, a resync event is sent and all dashboards have to be reloaded.
+// @Tags events
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "Id of the last received event"
+// @Param last_event_id query string false "Id of the last received event, for clients that can not set headers"
+// @Success 200 {object} Event
+// @Security Bearer
+// @Router /events [get]
+func getEventsEndpoint(c *gin.Context) {
+	lastEventId := c.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = c.Query("last_event_id")
+	}
+	sub, missed, complete := events.subscribe(getUserId(c), lastEventId)
+	defer events.unsubscribe(sub)
+	// the stream is open until the client disconnects or the server shuts down
+	err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	if err != nil {
+		log.Logger.Warn("clear write deadline of event stream failed", attributes.ErrorKey, err)
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if !complete {
+		c.Render(-1, sse.Event{Event: EventResync, Data: Event{Type: EventResync, Time: time.Now()}})
+	}
+	for _, event := range missed {
+		c.Render(-1, sse.Event{Id: event.Id, Event: event.Type, Data: event})
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(Config.EventKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.events:
+			if !ok {
+				// dropped by the broker, the client reconnects with Last-Event-ID
+				return
+			}
+			c.Render(-1, sse.Event{Id: event.Id, Event: event.Type, Data: event})
+			c.Writer.Flush()
+		case <-keepAlive.C:
+			_, err := c.Writer.WriteString(": keep-alive\n\n")
+			if err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// listWidgetTypesEndpoint godoc
+// @Summary List widget types
+// @Description Returns all known widget types with the JSON Schema their properties are validated against. Variable
+// @Description references are validated as the default of the variable, or an empty value of its type.
+// @Tags widgets
+// @Produce json
+// @Success 200 {array} WidgetType
+// @Security Bearer
+// @Router /widget-types [get]
+func listWidgetTypesEndpoint(c *gin.Context) {
+	c.JSON(http.StatusOK, listWidgetTypes())
+}
+
+// undoDashboardEndpoint godoc
+// @Summary Undo dashboard change
+// @Description Reverts the latest change of the current user to the widgets or metadata of the dashboard. Changes of
+// @Description other users to other widgets are kept. The number of changes kept per dashboard is limited. Moves of
+// @Description widgets between dashboards are not undoable and discard the changes of the moved widget.
+// @Tags dashboards
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Success 200 {object} UndoResult
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "nothing to undo or the restored widgets overlap others"
+// @Failure 422 {object} ErrorResponse "the restored widgets are no longer valid"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/undo [post]
+func undoDashboardEndpoint(c *gin.Context) {
+	result, err := undoDashboardChange(c.Request.Context(), c.Param("id"), false, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while undoing dashboard change"), err))
+		return
+	}
+	result.Dashboard = result.Dashboard.present(getUserId(c))
+	c.JSON(http.StatusOK, result)
+}
+
+// redoDashboardEndpoint godoc
+// @Summary Redo dashboard change
+// @Description Reapplies the latest change of the current user reverted with undo. New changes discard the reverted
+// @Description changes.
+// @Tags dashboards
+// @Produce json
+// @Param id path string true "Dashboard ID"
+// @Success 200 {object} UndoResult
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "nothing to redo or the restored widgets overlap others"
+// @Failure 422 {object} ErrorResponse "the restored widgets are no longer valid"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /dashboards/{id}/redo [post]
+func redoDashboardEndpoint(c *gin.Context) {
+	result, err := undoDashboardChange(c.Request.Context(), c.Param("id"), true, getUserId(c))
+	if err != nil {
+		_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while redoing dashboard change"), err))
+		return
+	}
+	result.Dashboard = result.Dashboard.present(getUserId(c))
+	c.JSON(http.StatusOK, result)
+}
+
+// batchEndpoint godoc
+// @Summary Run operations in a transaction
+// @Description Runs an ordered list of write operations in a single transaction. Operations are requests to the
+// @Description POST, PUT, PATCH and DELETE endpoints of this API with a JSON body and are authorized like separate
+// @Description requests. If an operation fails, no operation is applied and the status of the failed operation is
+// @Description returned. Each operation counts against the write rate limit, at most the burst size per batch.
+// @Tags batch
+// @Accept json
+// @Produce json
+// @Param request body BatchRequest true "Operations"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} BatchResponse
+// @Failure 403 {object} BatchResponse
+// @Failure 404 {object} BatchResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /batch [post]
+func batchEndpoint(handler http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request BatchRequest
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			_ = c.Error(errors.Join(bindError(err), errors.New("Error while parsing batch request"), err))
+			return
+		}
+		err = validateBatchRequest(request)
+		if err != nil {
+			_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while validating batch request"), err))
+			return
+		}
+		// the batch request took one token
+		if !takeRateLimit(c, min(len(request.Operations), Config.RateLimit.Write.Burst)-1) {
+			return
+		}
+		identity := batchIdentity{userId: getUserId(c), roles: getUserRoles(c)}
+		response, err := runBatch(c.Request.Context(), handler, c.Request, identity, request)
+		if err != nil {
+			_ = c.Error(errors.Join(GetError(GetStatusCode(err)), errors.New("Error while running batch"), err))
+			return
+		}
+		status := http.StatusOK
+		if response.RolledBack {
+			status = response.Results[len(response.Results)-1].Status
+		}
+		c.JSON(status, response)
+	}
+}
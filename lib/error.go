@@ -26,6 +26,11 @@ var ErrBadRequest = errors.New("bad request")
 var ErrInternalServerError = errors.New("internal server error")
 var ErrForbidden = fmt.Errorf("forbidden")
 var ErrNotFound = fmt.Errorf("not found")
+var ErrUnauthorized = errors.New("unauthorized")
+var ErrPayloadTooLarge = errors.New("payload too large")
+var ErrUnprocessableEntity = errors.New("unprocessable entity")
+var ErrConflict = errors.New("conflict")
+var ErrTooManyRequests = errors.New("too many requests")
 
 func GetStatusCode(err error) int {
 	if err == nil {
@@ -43,6 +48,21 @@ func GetStatusCode(err error) int {
 	if errors.Is(err, ErrForbidden) {
 		return http.StatusForbidden
 	}
+	if errors.Is(err, ErrUnauthorized) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, ErrPayloadTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	if errors.Is(err, ErrUnprocessableEntity) {
+		return http.StatusUnprocessableEntity
+	}
+	if errors.Is(err, ErrConflict) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, ErrTooManyRequests) {
+		return http.StatusTooManyRequests
+	}
 	return http.StatusInternalServerError
 }
 
@@ -58,6 +78,16 @@ func GetError(code int) error {
 		return ErrNotFound
 	case http.StatusForbidden:
 		return ErrForbidden
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusRequestEntityTooLarge:
+		return ErrPayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return ErrUnprocessableEntity
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
 	default:
 		return ErrInternalServerError
 	}
@@ -0,0 +1,239 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventDashboardCreated = "dashboard_created"
+	EventDashboardUpdated = "dashboard_updated"
+	EventDashboardDeleted = "dashboard_deleted"
+	EventWidgetAdded      = "widget_added"
+	EventWidgetChanged    = "widget_changed"
+	EventWidgetMoved      = "widget_moved"
+	EventWidgetRemoved    = "widget_removed"
+	// EventResync tells the client that events were missed and all dashboards have to be reloaded.
+	EventResync = "resync"
+)
+
+type Event struct {
+	Id          string    `json:"id"`
+	Type        string    `json:"type"`
+	DashboardId string    `json:"dashboard_id,omitempty"`
+	WidgetId    string    `json:"widget_id,omitempty"`
+	ActorId     string    `json:"actor_id,omitempty"`
+	Time        time.Time `json:"time"`
+	// Recipients are the owner and the users the dashboard is shared with.
+	Recipients []string `json:"-"`
+}
+
+// newDashboardEvent creates the event for the dashboard. Users with access to any of the given versions receive it.
+func newDashboardEvent(eventType string, dash Dashboard, widgetId string, actorId string, versions ...Dashboard) Event {
+	return Event{
+		Type:        eventType,
+		DashboardId: dash.Id.Hex(),
+		WidgetId:    widgetId,
+		ActorId:     actorId,
+		Time:        time.Now(),
+		Recipients:  dashboardUsers(dash, versions...),
+	}
+}
+
+// dashboardUsers returns the owner and all users the dashboard is shared with in any of the versions.
+func dashboardUsers(dash Dashboard, versions ...Dashboard) []string {
+	result := []string{dash.UserId}
+	for _, version := range append([]Dashboard{dash}, versions...) {
+		for _, share := range version.Shares {
+			if !slices.Contains(result, share.UserId) {
+				result = append(result, share.UserId)
+			}
+		}
+	}
+	return result
+}
+
+type eventSubscription struct {
+	userId string
+	events chan Event
+}
+
+// eventBroker distributes events to the subscribers in this process and keeps the latest events to resume streams.
+// Event ids are prefixed with the start time of the broker, ids of another process can not be resumed.
+type eventBroker struct {
+	mux         sync.Mutex
+	epoch       int64
+	seq         uint64
+	history     []Event
+	historySize int
+	subscribers map[*eventSubscription]bool
+}
+
+const eventSubscriptionBuffer = 64
+
+var events = newEventBroker(1000)
+
+// InitEvents creates the event broker with the configured history size.
+func InitEvents() {
+	events = newEventBroker(Config.EventHistorySize)
+}
+
+func newEventBroker(historySize int) *eventBroker {
+	return &eventBroker{
+		epoch:       time.Now().UnixNano(),
+		historySize: historySize,
+		subscribers: map[*eventSubscription]bool{},
+	}
+}
+
+func (this *eventBroker) publish(event Event) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.seq++
+	event.Id = fmt.Sprintf("%d-%d", this.epoch, this.seq)
+	if this.historySize > 0 {
+		if len(this.history) >= this.historySize {
+			this.history = this.history[1:]
+		}
+		this.history = append(this.history, event)
+	}
+	for sub := range this.subscribers {
+		if !slices.Contains(event.Recipients, sub.userId) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// slow subscribers are dropped and resume with Last-Event-ID
+			close(sub.events)
+			delete(this.subscribers, sub)
+		}
+	}
+}
+
+// subscribe registers a subscriber for the events of the user. If lastEventId is set, the events published since are
+// returned. complete is false if these events are no longer known and the client has to resync.
+func (this *eventBroker) subscribe(userId string, lastEventId string) (sub *eventSubscription, missed []Event, complete bool) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	sub = &eventSubscription{userId: userId, events: make(chan Event, eventSubscriptionBuffer)}
+	this.subscribers[sub] = true
+	complete = true
+	if lastEventId != "" {
+		missed, complete = this.eventsSince(userId, lastEventId)
+	}
+	return sub, missed, complete
+}
+
+func (this *eventBroker) unsubscribe(sub *eventSubscription) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if this.subscribers[sub] {
+		close(sub.events)
+		delete(this.subscribers, sub)
+	}
+}
+
+// close ends all subscriptions, used on shutdown.
+func (this *eventBroker) close() {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for sub := range this.subscribers {
+		close(sub.events)
+		delete(this.subscribers, sub)
+	}
+}
+
+func (this *eventBroker) eventsSince(userId string, lastEventId string) (result []Event, complete bool) {
+	epoch, seq, err := parseEventId(lastEventId)
+	if err != nil || epoch != this.epoch || seq > this.seq {
+		return nil, false
+	}
+	if seq == this.seq {
+		return nil, true
+	}
+	// history holds consecutive sequence numbers ending at this.seq
+	first := this.seq - uint64(len(this.history)) + 1
+	if len(this.history) == 0 || seq+1 < first {
+		return nil, false
+	}
+	for _, event := range this.history[seq+1-first:] {
+		if slices.Contains(event.Recipients, userId) {
+			result = append(result, event)
+		}
+	}
+	return result, true
+}
+
+func parseEventId(id string) (epoch int64, seq uint64, err error) {
+	epochPart, seqPart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid event id %q", id)
+	}
+	epoch, err = strconv.ParseInt(epochPart, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	seq, err = strconv.ParseUint(seqPart, 10, 64)
+	return epoch, seq, err
+}
+
+// recordDashboardChange writes the event to the outbox, the audit log, the tombstones of removed dashboards and
+// widgets and the undo stack of the actor in the transaction of ctx and publishes it to the event subscribers once
+// the transaction is committed. before is nil for created and after for deleted dashboards.
+func recordDashboardChange(ctx context.Context, eventType string, before *Dashboard, after *Dashboard, widgetId string, actorId string) error {
+	collector, ok := ctx.Value(eventCollectorContextKey{}).(*eventCollector)
+	if !ok {
+		return withTransaction(ctx, func(ctx context.Context) error {
+			return recordDashboardChange(ctx, eventType, before, after, widgetId, actorId)
+		})
+	}
+	var event Event
+	switch {
+	case before == nil:
+		event = newDashboardEvent(eventType, *after, widgetId, actorId)
+	case after == nil:
+		event = newDashboardEvent(eventType, *before, widgetId, actorId)
+	default:
+		event = newDashboardEvent(eventType, *after, widgetId, actorId, *before)
+	}
+	err := writeOutboxEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+	err = recordAudit(ctx, newDashboardAuditEntry(eventType, before, after, widgetId, actorId))
+	if err != nil {
+		return err
+	}
+	err = writeTombstones(ctx, before, after)
+	if err != nil {
+		return err
+	}
+	err = recordUndo(ctx, eventType, before, after, widgetId, actorId)
+	if err != nil {
+		return err
+	}
+	collector.events = append(collector.events, event)
+	return nil
+}
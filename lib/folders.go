@@ -0,0 +1,364 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RootFolder selects dashboards and folders that are not inside a folder.
+const RootFolder = "root"
+
+type Folder struct {
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `json:"name"`
+	UserId    string             `json:"user_id,omitempty"`
+	ParentId  string             `bson:"parentid" json:"parent_id"`
+	Index     uint16             `json:"index"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt,omitempty"`
+}
+
+type DashboardFolderMove struct {
+	FolderId string `json:"folder_id"`
+	Index    *int   `json:"index,omitempty"`
+}
+
+// DashboardFilter restricts the dashboard list. Folder may be a folder id or RootFolder, all tags have to match.
+type DashboardFilter struct {
+	Folder string
+	Tags   []string
+}
+
+func (this DashboardFilter) isEmpty() bool {
+	return this.Folder == "" && len(this.Tags) == 0
+}
+
+func (this Folder) auditSummary() *AuditSummary {
+	return &AuditSummary{Name: this.Name, FolderId: this.ParentId}
+}
+
+func folderFilter(folderId string) interface{} {
+	if folderId == "" || folderId == RootFolder {
+		return bson.M{"$in": bson.A{"", nil}}
+	}
+	return folderId
+}
+
+func getFolder(ctx context.Context, id string, userId string) (folder Folder, err error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return folder, normalizeModelError(err)
+	}
+	err = MongoFolders().FindOne(ctx, bson.M{"_id": objectId, "userid": userId}).Decode(&folder)
+	if err != nil {
+		return folder, normalizeModelError(err)
+	}
+	return folder, nil
+}
+
+func listFolders(ctx context.Context, userId string) (folders []Folder, err error) {
+	opts := options.Find().SetSort(bson.D{{Key: "parentid", Value: 1}, {Key: "index", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := MongoFolders().Find(ctx, bson.M{"userid": userId}, opts)
+	if err != nil {
+		log.Logger.Error("find folders failed", attributes.ErrorKey, err)
+		return nil, normalizeModelError(err)
+	}
+	folders = []Folder{}
+	if err = cur.All(ctx, &folders); err != nil {
+		return nil, normalizeModelError(err)
+	}
+	return folders, nil
+}
+
+// checkFolderParent ensures the parent exists and that the folder would not become its own ancestor. The ancestors
+// are written in the transaction of ctx, so concurrent moves of them conflict instead of creating a cycle.
+func checkFolderParent(ctx context.Context, folderId primitive.ObjectID, parentId string, userId string) error {
+	for parentId != "" {
+		if parentId == folderId.Hex() {
+			return errors.Join(ErrUnprocessableEntity, errors.New("folder can not be moved into itself"))
+		}
+		parent, err := getFolder(ctx, parentId, userId)
+		if err != nil {
+			return errors.Join(ErrUnprocessableEntity, errors.New("parent folder not found"), err)
+		}
+		_, err = MongoFolders().UpdateOne(ctx, bson.M{"_id": parent.Id}, bson.M{"$inc": bson.M{"revision": 1}})
+		if err != nil {
+			return normalizeModelError(err)
+		}
+		parentId = parent.ParentId
+	}
+	return nil
+}
+
+func createFolder(ctx context.Context, folder Folder, userId string) (Folder, error) {
+	if folder.Name == "" {
+		return folder, errors.Join(ErrBadRequest, errors.New("folder name is empty"))
+	}
+	if folder.ParentId == RootFolder {
+		folder.ParentId = ""
+	}
+	folder.Id = primitive.NewObjectID()
+	folder.UserId = userId
+	folder.UpdatedAt = time.Now()
+	var result Folder
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		err := checkFolderParent(ctx, folder.Id, folder.ParentId, userId)
+		if err != nil {
+			return err
+		}
+		_, err = MongoFolders().InsertOne(ctx, folder)
+		if err != nil {
+			log.Logger.Error("create folder failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		// the index of the request is the position among the sibling folders
+		err = renumberFolders(ctx, folder.ParentId, userId, folder.Id, int(folder.Index))
+		if err != nil {
+			return err
+		}
+		result, err = getFolder(ctx, folder.Id.Hex(), userId)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, AuditEntry{Action: AuditFolderCreated, ActorId: userId, FolderId: folder.Id.Hex(), After: folder.auditSummary()})
+	})
+	if err != nil {
+		return folder, err
+	}
+	return result, nil
+}
+
+func updateFolder(ctx context.Context, id string, folder Folder, userId string) (Folder, error) {
+	if folder.Name == "" {
+		return folder, errors.Join(ErrBadRequest, errors.New("folder name is empty"))
+	}
+	if folder.ParentId == RootFolder {
+		folder.ParentId = ""
+	}
+	var result Folder
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		old, err := getFolder(ctx, id, userId)
+		if err != nil {
+			return err
+		}
+		err = checkFolderParent(ctx, old.Id, folder.ParentId, userId)
+		if err != nil {
+			return err
+		}
+		folder.Id = old.Id
+		folder.UserId = userId
+		folder.UpdatedAt = time.Now()
+		_, err = MongoFolders().ReplaceOne(ctx, bson.M{"_id": old.Id, "userid": userId}, folder)
+		if err != nil {
+			log.Logger.Error("update folder failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		err = renumberFolders(ctx, folder.ParentId, userId, folder.Id, int(folder.Index))
+		if err != nil {
+			return err
+		}
+		if old.ParentId != folder.ParentId {
+			err = renumberFolders(ctx, old.ParentId, userId, primitive.NilObjectID, 0)
+			if err != nil {
+				return err
+			}
+		}
+		result, err = getFolder(ctx, id, userId)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, AuditEntry{Action: AuditFolderUpdated, ActorId: userId, FolderId: folder.Id.Hex(), Before: old.auditSummary(), After: folder.auditSummary()})
+	})
+	if err != nil {
+		return folder, err
+	}
+	return result, nil
+}
+
+// deleteFolder moves the sub folders and dashboards of the folder to its parent before deleting it.
+func deleteFolder(ctx context.Context, id string, userId string) error {
+	return withTransaction(ctx, func(ctx context.Context) error {
+		folder, err := getFolder(ctx, id, userId)
+		if err != nil {
+			return err
+		}
+		_, err = MongoFolders().UpdateMany(ctx, bson.M{"userid": userId, "parentid": id}, bson.M{"$set": bson.M{"parentid": folder.ParentId}})
+		if err != nil {
+			log.Logger.Error("move sub folders to parent failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		cur, err := Mongo().Find(ctx, bson.M{"userid": userId, "folderid": id})
+		if err != nil {
+			return normalizeModelError(err)
+		}
+		var dashs []Dashboard
+		if err = cur.All(ctx, &dashs); err != nil {
+			return normalizeModelError(err)
+		}
+		_, err = Mongo().UpdateMany(ctx, bson.M{"userid": userId, "folderid": id}, bson.M{"$set": bson.M{"folderid": folder.ParentId, "updatedAt": time.Now()}})
+		if err != nil {
+			log.Logger.Error("move folder dashboards to parent failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		for _, dash := range dashs {
+			after := dash
+			after.FolderId = folder.ParentId
+			err = recordDashboardChange(ctx, EventDashboardUpdated, &dash, &after, "", userId)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = MongoFolders().DeleteOne(ctx, bson.M{"_id": folder.Id, "userid": userId})
+		if err != nil {
+			log.Logger.Error("delete folder failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		err = recordAudit(ctx, AuditEntry{Action: AuditFolderDeleted, ActorId: userId, FolderId: id, Before: folder.auditSummary()})
+		if err != nil {
+			return err
+		}
+		err = renumberFolders(ctx, folder.ParentId, userId, primitive.NilObjectID, 0)
+		if err != nil {
+			return err
+		}
+		return renumberFolderDashboards(ctx, folder.ParentId, userId, primitive.NilObjectID, 0)
+	})
+}
+
+// moveDashboardToFolder moves a dashboard of the user into a folder, at the given position or at the end.
+func moveDashboardToFolder(ctx context.Context, dashboardId string, move DashboardFolderMove, userId string) error {
+	if move.FolderId == RootFolder {
+		move.FolderId = ""
+	}
+	return withTransaction(ctx, func(ctx context.Context) error {
+		dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleOwner)
+		if err != nil {
+			return err
+		}
+		if move.FolderId != "" {
+			_, err = getFolder(ctx, move.FolderId, userId)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = Mongo().UpdateOne(ctx, bson.M{"_id": dash.Id, "userid": userId}, bson.M{"$set": bson.M{"folderid": move.FolderId, "updatedAt": time.Now()}})
+		if err != nil {
+			log.Logger.Error("move dashboard to folder failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		index := -1
+		if move.Index != nil {
+			index = *move.Index
+		}
+		err = renumberFolderDashboards(ctx, move.FolderId, userId, dash.Id, index)
+		if err != nil {
+			return err
+		}
+		if dash.FolderId != move.FolderId {
+			err = renumberFolderDashboards(ctx, dash.FolderId, userId, primitive.NilObjectID, 0)
+			if err != nil {
+				return err
+			}
+		}
+		after := dash
+		after.FolderId = move.FolderId
+		return recordDashboardChange(ctx, EventDashboardUpdated, &dash, &after, "", userId)
+	})
+}
+
+// renumberFolderDashboards assigns consecutive folder indices to the dashboards of a folder. If moved is set, that
+// dashboard is placed at index, a negative or too large index appends it.
+func renumberFolderDashboards(ctx context.Context, folderId string, userId string, moved primitive.ObjectID, index int) error {
+	opts := options.Find().SetSort(bson.D{{Key: "folderindex", Value: 1}, {Key: "index", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := Mongo().Find(ctx, bson.M{"userid": userId, "folderid": folderFilter(folderId)}, opts)
+	if err != nil {
+		return normalizeModelError(err)
+	}
+	var dashs []Dashboard
+	if err = cur.All(ctx, &dashs); err != nil {
+		return normalizeModelError(err)
+	}
+	if !moved.IsZero() {
+		for i, dash := range dashs {
+			if dash.Id == moved {
+				movedDash := dash
+				dashs = removeAt(dashs, i)
+				if index < 0 || index > len(dashs) {
+					index = len(dashs)
+				}
+				dashs = insertAt(dashs, movedDash, index)
+				break
+			}
+		}
+	}
+	for i, dash := range dashs {
+		folderIndex := uint16(i)
+		if dash.FolderIndex != nil && *dash.FolderIndex == folderIndex {
+			continue
+		}
+		_, err = Mongo().UpdateOne(ctx, bson.M{"_id": dash.Id}, bson.M{"$set": bson.M{"folderindex": folderIndex}})
+		if err != nil {
+			log.Logger.Error("update dashboard folder index failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+	}
+	return nil
+}
+
+// renumberFolders numbers the sub folders of the parent consecutively, moving the folder moved to index if it is set.
+func renumberFolders(ctx context.Context, parentId string, userId string, moved primitive.ObjectID, index int) error {
+	opts := options.Find().SetSort(bson.D{{Key: "index", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := MongoFolders().Find(ctx, bson.M{"userid": userId, "parentid": folderFilter(parentId)}, opts)
+	if err != nil {
+		return normalizeModelError(err)
+	}
+	var folders []Folder
+	if err = cur.All(ctx, &folders); err != nil {
+		return normalizeModelError(err)
+	}
+	if !moved.IsZero() {
+		for i, folder := range folders {
+			if folder.Id == moved {
+				folders = removeAt(folders, i)
+				if index < 0 || index > len(folders) {
+					index = len(folders)
+				}
+				folders = insertAt(folders, folder, index)
+				break
+			}
+		}
+	}
+	for i, folder := range folders {
+		if folder.Index == uint16(i) {
+			continue
+		}
+		_, err = MongoFolders().UpdateOne(ctx, bson.M{"_id": folder.Id}, bson.M{"$set": bson.M{"index": uint16(i)}})
+		if err != nil {
+			log.Logger.Error("update folder index failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+	}
+	return nil
+}
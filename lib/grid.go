@@ -0,0 +1,374 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	CollisionModeReject  = "reject"
+	CollisionModeResolve = "resolve"
+)
+
+const (
+	GravityVertical   = "vertical"
+	GravityHorizontal = "horizontal"
+)
+
+// GridConfig describes the dashboard grid. MaxWidth and MaxHeight of 0 are limited by the column or row count.
+type GridConfig struct {
+	Columns int
+	// Rows limits the bottom edge of the widgets.
+	Rows          int
+	MinWidth      int
+	MaxWidth      int
+	MinHeight     int
+	MaxHeight     int
+	DefaultWidth  int
+	DefaultHeight int
+	// CollisionMode decides whether overlapping widgets are rejected or pushed down.
+	CollisionMode string
+}
+
+type rect struct {
+	x, y, w, h int
+}
+
+func (this rect) transposed() rect {
+	return rect{x: this.y, y: this.x, w: this.h, h: this.w}
+}
+
+func (this rect) overlaps(other rect) bool {
+	return this.x < other.x+other.w && other.x < this.x+this.w &&
+		this.y < other.y+other.h && other.y < this.y+this.h
+}
+
+// widgetRect returns the position of a widget, ok is false for widgets without complete position.
+func widgetRect(widget Widget) (result rect, ok bool) {
+	if widget.X == nil || widget.Y == nil || widget.W == nil || widget.H == nil {
+		return result, false
+	}
+	return rect{x: *widget.X, y: *widget.Y, w: *widget.W, h: *widget.H}, true
+}
+
+func (this GridConfig) maxWidth() int {
+	if this.MaxWidth > 0 && this.MaxWidth < this.Columns {
+		return this.MaxWidth
+	}
+	return this.Columns
+}
+
+func (this GridConfig) maxHeight() int {
+	if this.MaxHeight > 0 && this.MaxHeight < this.Rows {
+		return this.MaxHeight
+	}
+	return this.Rows
+}
+
+func (this GridConfig) validateRect(r rect) error {
+	if r.x < 0 || r.y < 0 {
+		return errors.Join(ErrUnprocessableEntity, fmt.Errorf("widget position %d/%d is negative", r.x, r.y))
+	}
+	if r.w < this.MinWidth || r.w > this.maxWidth() {
+		return errors.Join(ErrUnprocessableEntity, fmt.Errorf("widget width %d not within %d and %d", r.w, this.MinWidth, this.maxWidth()))
+	}
+	if r.h < this.MinHeight || r.h > this.maxHeight() {
+		return errors.Join(ErrUnprocessableEntity, fmt.Errorf("widget height %d not within %d and %d", r.h, this.MinHeight, this.maxHeight()))
+	}
+	if r.x+r.w > this.Columns {
+		return errors.Join(ErrUnprocessableEntity, fmt.Errorf("widget exceeds grid of %d columns", this.Columns))
+	}
+	if r.y+r.h > this.Rows {
+		return errors.Join(ErrUnprocessableEntity, fmt.Errorf("widget exceeds grid of %d rows", this.Rows))
+	}
+	return nil
+}
+
+// findFreeSlot returns the first position, row by row, where a widget of the given size does not overlap occupied.
+// The first free position is at the top or left edge of the grid or at the bottom or right edge of an occupied rect,
+// only these are searched. Below all occupied rows every position is free, so the search ends there.
+func (this GridConfig) findFreeSlot(occupied []rect, w int, h int) (x int, y int, err error) {
+	if w <= 0 || w > this.Columns {
+		return 0, 0, errors.Join(ErrUnprocessableEntity, fmt.Errorf("widget width %d not within 1 and %d", w, this.Columns))
+	}
+	rows, columns := []int{0}, []int{0}
+	for _, o := range occupied {
+		rows = append(rows, o.y+o.h)
+		columns = append(columns, o.x+o.w)
+	}
+	slices.Sort(rows)
+	slices.Sort(columns)
+	rows, columns = slices.Compact(rows), slices.Compact(columns)
+	for _, y = range rows {
+		for _, x = range columns {
+			if x+w <= this.Columns && !overlapsAny(rect{x: x, y: y, w: w, h: h}, occupied) {
+				return x, y, nil
+			}
+		}
+	}
+	return 0, rows[len(rows)-1], nil
+}
+
+// validate checks the configuration, invalid sizes would make widgets impossible to place.
+func (this GridConfig) validate() error {
+	if this.Columns <= 0 {
+		return fmt.Errorf("grid columns %d not positive", this.Columns)
+	}
+	if this.MinWidth <= 0 || this.MinWidth > this.DefaultWidth || this.DefaultWidth > this.maxWidth() {
+		return fmt.Errorf("grid widths not within 0 < min %d <= default %d <= max %d <= columns %d", this.MinWidth, this.DefaultWidth, this.MaxWidth, this.Columns)
+	}
+	if this.MaxWidth < 0 || this.MaxWidth > this.Columns {
+		return fmt.Errorf("grid max width %d not within 0 and %d columns", this.MaxWidth, this.Columns)
+	}
+	if this.Rows <= 0 {
+		return fmt.Errorf("grid rows %d not positive", this.Rows)
+	}
+	if this.MinHeight <= 0 || this.MinHeight > this.DefaultHeight || this.DefaultHeight > this.maxHeight() {
+		return fmt.Errorf("grid heights not within 0 < min %d <= default %d <= max %d <= rows %d", this.MinHeight, this.DefaultHeight, this.MaxHeight, this.Rows)
+	}
+	if this.MaxHeight < 0 || this.MaxHeight > this.Rows {
+		return fmt.Errorf("grid max height %d not within 0 and %d rows", this.MaxHeight, this.Rows)
+	}
+	if this.CollisionMode != CollisionModeReject && this.CollisionMode != CollisionModeResolve {
+		return fmt.Errorf("unknown grid collision mode %q", this.CollisionMode)
+	}
+	return nil
+}
+
+// arrangeWidget validates the layout of the widget at index, places it into the first free slot if it has no
+// coordinates and handles collisions with the other widgets according to the collision mode.
+func (this GridConfig) arrangeWidget(widgets []Widget, index int) error {
+	return this.arrangeMovedWidgets(widgets, []int{index})
+}
+
+// arrangeMovedWidgets is arrangeWidget for several widgets changed at once. Collisions are checked on the final
+// layout, so the result does not depend on the order of the moved widgets. Moved widgets overlapping each other are
+// rejected in both collision modes.
+func (this GridConfig) arrangeMovedWidgets(widgets []Widget, moved []int) error {
+	positioned := []int{}
+	unpositioned := []int{}
+	for _, index := range moved {
+		widget := &widgets[index]
+		if widget.W == nil {
+			w := min(this.DefaultWidth, this.maxWidth())
+			widget.W = &w
+		}
+		if widget.H == nil {
+			h := this.DefaultHeight
+			widget.H = &h
+		}
+		if widget.X == nil || widget.Y == nil {
+			err := this.validateRect(rect{w: *widget.W, h: *widget.H})
+			if err != nil {
+				return err
+			}
+			unpositioned = append(unpositioned, index)
+			continue
+		}
+		r, _ := widgetRect(*widget)
+		err := this.validateRect(r)
+		if err != nil {
+			return err
+		}
+		positioned = append(positioned, index)
+	}
+	collides := false
+	for _, index := range positioned {
+		r, _ := widgetRect(widgets[index])
+		for i, other := range widgets {
+			o, ok := widgetRect(other)
+			if !ok || i == index || !r.overlaps(o) {
+				continue
+			}
+			if this.CollisionMode == CollisionModeReject || slices.Contains(positioned, i) {
+				return errors.Join(ErrConflict, fmt.Errorf("widget at %d/%d overlaps another widget", r.x, r.y))
+			}
+			collides = true
+		}
+	}
+	if collides {
+		err := this.pushDown(widgets, positioned...)
+		if err != nil {
+			return err
+		}
+	}
+	for _, index := range unpositioned {
+		occupied := []rect{}
+		for i, other := range widgets {
+			if r, ok := widgetRect(other); ok && i != index {
+				occupied = append(occupied, r)
+			}
+		}
+		x, y, err := this.findFreeSlot(occupied, *widgets[index].W, *widgets[index].H)
+		if err != nil {
+			return err
+		}
+		if y+*widgets[index].H > this.Rows {
+			return errors.Join(ErrConflict, fmt.Errorf("no free slot within %d rows", this.Rows))
+		}
+		widgets[index].X = &x
+		widgets[index].Y = &y
+	}
+	return nil
+}
+
+// pushDown moves all widgets overlapping the fixed widgets, or widgets moved because of them, below them. Widgets
+// pushed beyond the rows of the grid are rejected.
+func (this GridConfig) pushDown(widgets []Widget, fixed ...int) error {
+	settled := []rect{}
+	for _, index := range fixed {
+		r, _ := widgetRect(widgets[index])
+		settled = append(settled, r)
+	}
+	others := []int{}
+	for i, widget := range widgets {
+		if _, ok := widgetRect(widget); ok && !slices.Contains(fixed, i) {
+			others = append(others, i)
+		}
+	}
+	sort.SliceStable(others, func(a, b int) bool {
+		ra, _ := widgetRect(widgets[others[a]])
+		rb, _ := widgetRect(widgets[others[b]])
+		if ra.y != rb.y {
+			return ra.y < rb.y
+		}
+		return ra.x < rb.x
+	})
+	for _, i := range others {
+		r, _ := widgetRect(widgets[i])
+		for moved := true; moved; {
+			moved = false
+			for _, s := range settled {
+				if r.overlaps(s) {
+					r.y = s.y + s.h
+					moved = true
+				}
+			}
+		}
+		if r.y != *widgets[i].Y {
+			if r.y+r.h > this.Rows {
+				return errors.Join(ErrConflict, fmt.Errorf("widgets pushed beyond %d rows", this.Rows))
+			}
+			y := r.y
+			widgets[i].Y = &y
+		}
+		settled = append(settled, r)
+	}
+	return nil
+}
+
+func (this GridConfig) arrangeWidgets(widgets []Widget) error {
+	for i := range widgets {
+		err := this.arrangeWidget(widgets, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactUp moves r up to the bottom edge of the first placed widget in the way instead of passing it. Widgets
+// overlapping a placed widget stay at their place.
+func compactUp(r rect, placed []rect) rect {
+	top := 0
+	for _, p := range placed {
+		if p.x >= r.x+r.w || r.x >= p.x+p.w || p.y >= r.y+r.h {
+			continue
+		}
+		if p.y+p.h > r.y {
+			return r
+		}
+		top = max(top, p.y+p.h)
+	}
+	r.y = top
+	return r
+}
+
+// compact moves all widgets up (vertical gravity) or left (horizontal gravity) as far as possible without passing
+// other widgets, so the order of the widgets along the gravity axis is kept.
+// Widgets are processed in reading order along the gravity axis, ties are broken by widget id, so the
+// result only depends on the current layout. Widgets without coordinates are placed into free slots afterwards.
+func (this GridConfig) compact(widgets []Widget, gravity string) error {
+	if gravity != GravityVertical && gravity != GravityHorizontal {
+		return errors.Join(ErrBadRequest, fmt.Errorf("unknown gravity %q", gravity))
+	}
+	positioned := []int{}
+	unpositioned := []int{}
+	for i, widget := range widgets {
+		if _, ok := widgetRect(widget); ok {
+			positioned = append(positioned, i)
+		} else {
+			unpositioned = append(unpositioned, i)
+		}
+	}
+	sort.SliceStable(positioned, func(a, b int) bool {
+		ra, _ := widgetRect(widgets[positioned[a]])
+		rb, _ := widgetRect(widgets[positioned[b]])
+		primaryA, secondaryA, primaryB, secondaryB := ra.y, ra.x, rb.y, rb.x
+		if gravity == GravityHorizontal {
+			primaryA, secondaryA, primaryB, secondaryB = ra.x, ra.y, rb.x, rb.y
+		}
+		if primaryA != primaryB {
+			return primaryA < primaryB
+		}
+		if secondaryA != secondaryB {
+			return secondaryA < secondaryB
+		}
+		return strings.Compare(widgets[positioned[a]].Id.Hex(), widgets[positioned[b]].Id.Hex()) < 0
+	})
+
+	placed := []rect{}
+	for _, i := range positioned {
+		r, _ := widgetRect(widgets[i])
+		var candidate rect
+		if gravity == GravityVertical {
+			candidate = compactUp(r, placed)
+		} else {
+			// compacting to the left is compacting up with x and y swapped
+			transposed := []rect{}
+			for _, p := range placed {
+				transposed = append(transposed, p.transposed())
+			}
+			candidate = compactUp(r.transposed(), transposed).transposed()
+		}
+		x, y := candidate.x, candidate.y
+		widgets[i].X = &x
+		widgets[i].Y = &y
+		placed = append(placed, candidate)
+	}
+	for _, i := range unpositioned {
+		err := this.arrangeWidget(widgets, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func overlapsAny(r rect, others []rect) bool {
+	for _, other := range others {
+		if r.overlaps(other) {
+			return true
+		}
+	}
+	return false
+}
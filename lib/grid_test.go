@@ -0,0 +1,170 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"slices"
+	"testing"
+)
+
+func testGrid() GridConfig {
+	return GridConfig{Columns: 12, Rows: 100, MinWidth: 1, MinHeight: 1, DefaultWidth: 4, DefaultHeight: 2, CollisionMode: CollisionModeReject}
+}
+
+// testLayout returns a widget per rect, widgets of empty rects have no position.
+func testLayout(rects ...rect) []Widget {
+	widgets := []Widget{}
+	for _, r := range rects {
+		widget := Widget{}
+		if r != (rect{}) {
+			widget.X, widget.Y, widget.W, widget.H = &r.x, &r.y, &r.w, &r.h
+		}
+		widgets = append(widgets, widget)
+	}
+	return widgets
+}
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name    string
+		gravity string
+		widgets []rect
+		want    []rect
+	}{
+		{
+			name:    "gaps above widgets are closed",
+			gravity: GravityVertical,
+			widgets: []rect{{x: 0, y: 1, w: 4, h: 2}, {x: 0, y: 5, w: 4, h: 2}, {x: 4, y: 3, w: 4, h: 1}},
+			want:    []rect{{x: 0, y: 0, w: 4, h: 2}, {x: 0, y: 2, w: 4, h: 2}, {x: 4, y: 0, w: 4, h: 1}},
+		},
+		{
+			name:    "widget does not jump over another widget into a hole above",
+			gravity: GravityVertical,
+			widgets: []rect{{x: 2, y: 0, w: 4, h: 2}, {x: 0, y: 2, w: 4, h: 2}, {x: 0, y: 5, w: 2, h: 1}},
+			want:    []rect{{x: 2, y: 0, w: 4, h: 2}, {x: 0, y: 2, w: 4, h: 2}, {x: 0, y: 4, w: 2, h: 1}},
+		},
+		{
+			name:    "gaps left of widgets are closed",
+			gravity: GravityHorizontal,
+			widgets: []rect{{x: 1, y: 0, w: 2, h: 4}, {x: 5, y: 0, w: 2, h: 4}, {x: 3, y: 4, w: 1, h: 4}},
+			want:    []rect{{x: 0, y: 0, w: 2, h: 4}, {x: 2, y: 0, w: 2, h: 4}, {x: 0, y: 4, w: 1, h: 4}},
+		},
+		{
+			name:    "widget does not jump over another widget into a hole on the left",
+			gravity: GravityHorizontal,
+			widgets: []rect{{x: 0, y: 2, w: 2, h: 4}, {x: 2, y: 0, w: 2, h: 4}, {x: 5, y: 0, w: 1, h: 2}},
+			want:    []rect{{x: 0, y: 2, w: 2, h: 4}, {x: 2, y: 0, w: 2, h: 4}, {x: 4, y: 0, w: 1, h: 2}},
+		},
+		{
+			name:    "widget far below moves up at once",
+			gravity: GravityVertical,
+			widgets: []rect{{x: 0, y: 0, w: 4, h: 2}, {x: 2, y: 300000000, w: 4, h: 2}},
+			want:    []rect{{x: 0, y: 0, w: 4, h: 2}, {x: 2, y: 2, w: 4, h: 2}},
+		},
+		{
+			name:    "overlapping widgets stay at their place",
+			gravity: GravityVertical,
+			widgets: []rect{{x: 0, y: 0, w: 4, h: 2}, {x: 2, y: 1, w: 4, h: 2}},
+			want:    []rect{{x: 0, y: 0, w: 4, h: 2}, {x: 2, y: 1, w: 4, h: 2}},
+		},
+		{
+			name:    "widgets without position are placed into the first free slot",
+			gravity: GravityVertical,
+			widgets: []rect{{x: 0, y: 3, w: 12, h: 1}, {}},
+			want:    []rect{{x: 0, y: 0, w: 12, h: 1}, {x: 0, y: 1, w: 4, h: 2}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			widgets := testLayout(test.widgets...)
+			err := testGrid().compact(widgets, test.gravity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []rect{}
+			for _, widget := range widgets {
+				r, _ := widgetRect(widget)
+				got = append(got, r)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateRect(t *testing.T) {
+	tests := []struct {
+		name  string
+		rect  rect
+		valid bool
+	}{
+		{name: "within the grid", rect: rect{x: 8, y: 98, w: 4, h: 2}, valid: true},
+		{name: "negative position", rect: rect{x: 0, y: -1, w: 4, h: 2}},
+		{name: "beyond the columns", rect: rect{x: 9, y: 0, w: 4, h: 2}},
+		{name: "beyond the rows", rect: rect{x: 0, y: 99, w: 4, h: 2}},
+		{name: "far beyond the rows", rect: rect{x: 0, y: 300000000, w: 4, h: 2}},
+		{name: "higher than the rows", rect: rect{x: 0, y: 0, w: 4, h: 101}},
+		{name: "narrower than the minimum", rect: rect{x: 0, y: 0, w: 0, h: 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := testGrid().validateRect(test.rect)
+			if valid := err == nil; valid != test.valid {
+				t.Errorf("got valid %v, want %v: %v", valid, test.valid, err)
+			}
+		})
+	}
+}
+
+func TestArrangeWidgetBeyondRows(t *testing.T) {
+	grid := testGrid()
+	grid.CollisionMode = CollisionModeResolve
+	widgets := testLayout(rect{x: 0, y: 96, w: 4, h: 2}, rect{x: 0, y: 98, w: 4, h: 2})
+	y := 97
+	widgets[0].Y = &y
+	if err := grid.arrangeWidget(widgets, 0); err == nil {
+		t.Error("expected an error for a widget pushed beyond the rows")
+	}
+}
+
+func TestFindFreeSlot(t *testing.T) {
+	tests := []struct {
+		name     string
+		occupied []rect
+		w, h     int
+		x, y     int
+	}{
+		{name: "empty grid", w: 4, h: 2, x: 0, y: 0},
+		{name: "right of a widget", occupied: []rect{{x: 0, y: 0, w: 4, h: 2}}, w: 4, h: 2, x: 4, y: 0},
+		{name: "hole between widgets", occupied: []rect{{x: 0, y: 0, w: 3, h: 2}, {x: 7, y: 0, w: 5, h: 2}}, w: 4, h: 2, x: 3, y: 0},
+		{name: "below a full row", occupied: []rect{{x: 0, y: 0, w: 12, h: 3}}, w: 4, h: 2, x: 0, y: 3},
+		{name: "below a high widget", occupied: []rect{{x: 0, y: 0, w: 12, h: 300000000}}, w: 4, h: 2, x: 0, y: 300000000},
+		{name: "next to the lowest widget", occupied: []rect{{x: 0, y: 0, w: 12, h: 1}, {x: 0, y: 1, w: 6, h: 5}, {x: 6, y: 1, w: 6, h: 2}}, w: 6, h: 2, x: 6, y: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			x, y, err := testGrid().findFreeSlot(test.occupied, test.w, test.h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if x != test.x || y != test.y {
+				t.Errorf("got %d/%d, want %d/%d", x, y, test.x, test.y)
+			}
+		})
+	}
+}
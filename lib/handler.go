@@ -26,6 +26,7 @@ import (
 	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 //go:generate go run github.com/swaggo/swag/cmd/swag@v1.16.3 init -o ../docs --parseDependency -d .. -g lib/handler.go
@@ -37,11 +38,16 @@ import (
 // @securityDefinitions.apikey Bearer
 // @in header
 // @name Authorization
-func CreateServer() {
-	log.Logger.Info("start server")
+func CreateServer() *Server {
+	log.Logger.Info("create server")
 
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	// gin trusts all proxies by default, clients could choose their ip by sending X-Forwarded-For
+	err := router.SetTrustedProxies(Config.Server.TrustedProxies)
+	if err != nil {
+		panic("invalid trusted proxies: " + err.Error())
+	}
 	router.Use(
 		gin_mw.StructLoggerHandlerWithDefaultGenerators(
 			log.Logger.With(attributes.LogRecordTypeKey, attributes.HttpAccessLogRecordTypeVal),
@@ -50,30 +56,91 @@ func CreateServer() {
 			nil,
 		),
 		requestid.New(requestid.WithCustomHeaderStrKey("X-Request-ID")),
+		metricsHandler,
+		securityHeadersHandler,
+	)
+	if cors := corsHandler(); cors != nil {
+		// before authentication, preflight requests carry no token
+		router.Use(cors)
+	}
+	router.Use(
 		gin_mw.ErrorHandler(GetStatusCode, ", "),
 		gin_mw.StructRecoveryHandler(log.Logger, gin_mw.DefaultRecoveryFunc),
+		bodySizeHandler,
+		auditHandler,
 	)
 
-	router.GET("/", getRootEndpoint)
-	router.GET("/doc", swaggerDocHandler)
-	router.GET("/dashboards", getDashboardsEndpoint)
-	router.POST("/dashboards", createDashboardEndpoint)
-	router.GET("/dashboards/:id", getDashboardEndpoint)
-	router.DELETE("/dashboards/:id", deleteDashboardEndpoint)
-	router.PUT("/dashboards/:id", editDashboardEndpoint)
-
-	router.PATCH("/widgets/positions", editWidgetPosition)
-	router.GET("/widgets/:dashboardId/:widgetId", getWidgetEndpoint)
-	router.POST("/widgets/:dashboardId", createWidgetEndpoint)
-	router.DELETE("/widgets/:dashboardId/:widgetId", deleteWidgetEndpoint)
-
-	router.PATCH("/widgets/name/:dashboardId/:widgetId", editWidgetNameEndpoint)
-	router.PATCH("/widgets/properties/*path", editWidgetPropertiesDispatchEndpoint)
-
-	log.Logger.Info("listen on port", "port", "8080")
-	err := http.ListenAndServe(":8080", router)
-	if err != nil {
-		log.Logger.Error("listen and serve failed", attributes.ErrorKey, err)
-		panic(err)
+	result := &Server{}
+	if Config.MetricsAddress == "" {
+		router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	} else {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		result.servers = append(result.servers, newHttpServer(Config.MetricsAddress, mux))
 	}
+
+	router.GET("/", getRootEndpoint)
+	router.GET("/health/live", getLivenessEndpoint)
+	router.GET("/health/ready", getReadinessEndpoint)
+	router.GET("/doc", conditionalGetHandler(Config.CacheControl.Public), swaggerDocHandler)
+	router.GET("/public/dashboards/:token", rateLimitHandler, conditionalGetHandler(Config.CacheControl.Public), getPublicDashboardEndpoint)
+
+	api := router.Group("", authHandler(), rateLimitHandler, conditionalGetHandler(Config.CacheControl.Private))
+	registerUserRoutes(api)
+	api.GET("/widget-types", listWidgetTypesEndpoint)
+	api.GET("/audit", adminHandler, listAuditEndpoint)
+	api.POST("/batch", batchEndpoint(router))
+
+	admin := api.Group("/admin", adminHandler)
+	admin.GET("/users", listUsersEndpoint)
+	admin.POST("/users/:userId/reset", resetUserEndpoint)
+	registerUserRoutes(admin.Group("/users/:userId", impersonationHandler))
+
+	apiServer := newHttpServer(Config.Server.ListenAddress, router)
+	// event streams would block the shutdown until its deadline
+	apiServer.RegisterOnShutdown(events.close)
+	result.servers = append(result.servers, apiServer)
+	return result
+}
+
+// registerUserRoutes registers the dashboard and widget routes acting on behalf of getUserId.
+func registerUserRoutes(group *gin.RouterGroup) {
+	group.GET("/dashboards", getDashboardsEndpoint)
+	group.POST("/dashboards", createDashboardEndpoint)
+	group.GET("/dashboards/changes", getDashboardChangesEndpoint)
+	group.GET("/dashboards/:id", getDashboardEndpoint)
+	group.DELETE("/dashboards/:id", deleteDashboardEndpoint)
+	group.PUT("/dashboards/:id", editDashboardEndpoint)
+	group.GET("/dashboards/:id/resolved", getResolvedDashboardEndpoint)
+	group.GET("/dashboards/:id/shares", getDashboardSharesEndpoint)
+	group.PUT("/dashboards/:id/shares", updateDashboardSharesEndpoint)
+	group.GET("/dashboards/:id/links", listShareLinksEndpoint)
+	group.POST("/dashboards/:id/links", createShareLinkEndpoint)
+	group.DELETE("/dashboards/:id/links/:linkId", deleteShareLinkEndpoint)
+	group.POST("/dashboards/:id/layout/compact", compactDashboardLayoutEndpoint)
+	group.POST("/dashboards/:id/undo", undoDashboardEndpoint)
+	group.POST("/dashboards/:id/redo", redoDashboardEndpoint)
+	group.PUT("/dashboards/:id/folder", moveDashboardToFolderEndpoint)
+
+	group.GET("/folders", listFoldersEndpoint)
+	group.POST("/folders", createFolderEndpoint)
+	group.GET("/folders/:id", getFolderEndpoint)
+	group.PUT("/folders/:id", updateFolderEndpoint)
+	group.DELETE("/folders/:id", deleteFolderEndpoint)
+
+	group.GET("/widgets", searchWidgetsEndpoint)
+	group.PATCH("/widgets/positions", editWidgetPosition)
+	group.GET("/widgets/:dashboardId/:widgetId", getWidgetEndpoint)
+	group.POST("/widgets/:dashboardId", createWidgetEndpoint)
+	group.DELETE("/widgets/:dashboardId/:widgetId", deleteWidgetEndpoint)
+
+	group.PATCH("/widgets/name/:dashboardId/:widgetId", editWidgetNameEndpoint)
+	group.PATCH("/widgets/properties/*path", editWidgetPropertiesDispatchEndpoint)
+
+	group.GET("/quota", getQuotaEndpoint)
+
+	group.GET("/events", getEventsEndpoint)
+
+	group.GET("/preferences", getPreferencesEndpoint)
+	group.PUT("/preferences", updatePreferencesEndpoint)
 }
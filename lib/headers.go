@@ -0,0 +1,66 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+type CorsConfig struct {
+	// AllowedOrigins enables CORS if not empty. Entries may be "*" or contain one wildcard, e.g. "https://*.example.com".
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// corsHandler answers preflight requests and adds the CORS headers, nil if CORS is disabled.
+func corsHandler() gin.HandlerFunc {
+	if len(Config.Cors.AllowedOrigins) == 0 {
+		return nil
+	}
+	return cors.New(cors.Config{
+		AllowOrigins:     Config.Cors.AllowedOrigins,
+		AllowWildcard:    true,
+		AllowMethods:     Config.Cors.AllowedMethods,
+		AllowHeaders:     Config.Cors.AllowedHeaders,
+		ExposeHeaders:    Config.Cors.ExposedHeaders,
+		AllowCredentials: Config.Cors.AllowCredentials,
+		MaxAge:           Config.Cors.MaxAge,
+	})
+}
+
+// securityHeadersHandler sets headers that keep browsers from sniffing, framing or executing responses.
+// The API only serves JSON, so the content security policy forbids everything.
+func securityHeadersHandler(c *gin.Context) {
+	header := c.Writer.Header()
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("X-Frame-Options", "DENY")
+	header.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("Cross-Origin-Opener-Policy", "same-origin")
+	if Config.HstsMaxAge > 0 {
+		header.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(Config.HstsMaxAge.Seconds()))+"; includeSubDomains")
+	}
+	c.Next()
+}
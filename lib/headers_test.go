@@ -0,0 +1,116 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSecurityHeadersHandler(t *testing.T) {
+	original := Config
+	defer func() { Config = original }()
+	tests := []struct {
+		name     string
+		hsts     time.Duration
+		wantHsts string
+	}{
+		{name: "with hsts", hsts: 24 * time.Hour, wantHsts: "max-age=86400; includeSubDomains"},
+		{name: "without hsts", hsts: 0, wantHsts: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			Config.HstsMaxAge = test.hsts
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(securityHeadersHandler)
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+			// unknown routes get the headers too
+			for _, path := range []string{"/", "/unknown"} {
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				for name, want := range map[string]string{
+					"X-Content-Type-Options":    "nosniff",
+					"X-Frame-Options":           "DENY",
+					"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+					"Strict-Transport-Security": test.wantHsts,
+				} {
+					if got := rec.Header().Get(name); got != want {
+						t.Errorf("%s: got %s %q, want %q", path, name, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCorsHandler(t *testing.T) {
+	original := Config
+	defer func() { Config = original }()
+	Config.Cors = CorsConfig{}
+	if corsHandler() != nil {
+		t.Fatal("got a cors handler without allowed origins")
+	}
+	Config.Cors = CorsConfig{
+		AllowedOrigins: []string{"https://*.example.com"},
+		AllowedMethods: []string{"GET", "PUT"},
+		AllowedHeaders: []string{"Authorization", "If-None-Match"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(corsHandler())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		wantCode   int
+		wantOrigin string
+	}{
+		{name: "preflight of an allowed origin", method: http.MethodOptions, origin: "https://app.example.com", wantCode: http.StatusNoContent, wantOrigin: "https://app.example.com"},
+		{name: "request of an allowed origin", method: http.MethodGet, origin: "https://app.example.com", wantCode: http.StatusOK, wantOrigin: "https://app.example.com"},
+		{name: "request of another origin", method: http.MethodGet, origin: "https://example.org", wantCode: http.StatusForbidden},
+		{name: "request without origin", method: http.MethodGet, wantCode: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, "/", nil)
+			if test.origin != "" {
+				req.Header.Set("Origin", test.origin)
+			}
+			if test.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+				req.Header.Set("Access-Control-Request-Headers", "Authorization")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != test.wantCode {
+				t.Fatalf("got status %d, want %d", rec.Code, test.wantCode)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != test.wantOrigin {
+				t.Errorf("got allowed origin %q, want %q", got, test.wantOrigin)
+			}
+		})
+	}
+}
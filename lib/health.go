@@ -0,0 +1,94 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+type HealthStatus struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+type HealthCheck struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Details    string `json:"details,omitempty"`
+}
+
+// migrationState is reported by the readiness check, the service is not ready before the migrations completed.
+var migrationState = struct {
+	sync.Mutex
+	completedAt *time.Time
+}{}
+
+func setMigrationsCompleted() {
+	migrationState.Lock()
+	defer migrationState.Unlock()
+	now := time.Now()
+	migrationState.completedAt = &now
+}
+
+func checkMongo(ctx context.Context) HealthCheck {
+	start := time.Now()
+	if DB == nil {
+		return HealthCheck{Status: HealthDown, Details: "not connected"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, Config.HealthTimeout)
+	defer cancel()
+	err := DB.Ping(ctx, nil)
+	check := HealthCheck{Status: HealthUp, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Status = HealthDown
+		check.Details = err.Error()
+	}
+	return check
+}
+
+func checkMigrations() HealthCheck {
+	migrationState.Lock()
+	defer migrationState.Unlock()
+	if migrationState.completedAt == nil {
+		return HealthCheck{Status: HealthDown, Details: "pending"}
+	}
+	return HealthCheck{Status: HealthUp, Details: "completed at " + migrationState.completedAt.Format(time.RFC3339)}
+}
+
+// getReadiness checks all components the service needs to handle requests.
+func getReadiness(ctx context.Context) HealthStatus {
+	result := HealthStatus{Status: HealthUp, Checks: map[string]HealthCheck{
+		"mongodb":    checkMongo(ctx),
+		"migrations": checkMigrations(),
+	}}
+	if shuttingDown.Load() {
+		result.Checks["server"] = HealthCheck{Status: HealthDown, Details: "shutting down"}
+	}
+	for _, check := range result.Checks {
+		if check.Status != HealthUp {
+			result.Status = HealthDown
+		}
+	}
+	return result
+}
@@ -18,7 +18,11 @@
 
 package lib
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+)
 
 func GetEnv(key, fallback string) string {
 	value := os.Getenv(key)
@@ -26,4 +30,28 @@ func GetEnv(key, fallback string) string {
 		return fallback
 	}
 	return value
-}
\ No newline at end of file
+}
+
+func GetEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return fallback
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		panic("invalid value for " + key + ": " + err.Error())
+	}
+	return result
+}
+
+// splitList splits a comma separated value, ignoring empty entries.
+func splitList(value string) []string {
+	result := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
@@ -0,0 +1,207 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Breakpoint is a named grid size, e.g. for phones or wall displays. Widgets may carry an explicit layout per
+// breakpoint, missing layouts are derived from the default layout of the widget.
+type Breakpoint struct {
+	Name    string
+	Columns int
+}
+
+type BreakpointLayout struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+	// Derived is set for layouts generated by the server because the widget has no explicit layout for the breakpoint.
+	Derived bool `bson:"-" json:"derived,omitempty"`
+}
+
+// parseBreakpoints parses a comma separated list of name:columns pairs, e.g. "lg:12,md:10,sm:6".
+func parseBreakpoints(value string) []Breakpoint {
+	result := []Breakpoint{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, columns, found := strings.Cut(entry, ":")
+		cols, err := strconv.Atoi(columns)
+		if !found || name == "" || err != nil || cols <= 0 {
+			panic("invalid breakpoint: " + entry)
+		}
+		result = append(result, Breakpoint{Name: name, Columns: cols})
+	}
+	return result
+}
+
+func findBreakpoint(name string) (Breakpoint, error) {
+	for _, bp := range Config.Breakpoints {
+		if bp.Name == name {
+			return bp, nil
+		}
+	}
+	return Breakpoint{}, errors.Join(ErrBadRequest, fmt.Errorf("unknown breakpoint %q", name))
+}
+
+func (this Breakpoint) grid() GridConfig {
+	grid := Config.Grid
+	grid.Columns = this.Columns
+	return grid
+}
+
+func (this BreakpointLayout) rect() rect {
+	return rect{x: this.X, y: this.Y, w: this.W, h: this.H}
+}
+
+// normalizeLayouts drops derived layouts sent back by clients and validates the explicit ones.
+func normalizeLayouts(widget *Widget) error {
+	for name, layout := range widget.Layouts {
+		if layout.Derived {
+			delete(widget.Layouts, name)
+			continue
+		}
+		bp, err := findBreakpoint(name)
+		if err != nil {
+			return err
+		}
+		err = bp.grid().validateRect(layout.rect())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setBreakpointLayouts updates the explicit layouts of the widgets at the indices of positions for the breakpoint.
+// Collisions are only checked against other explicit layouts of the breakpoint, derived layouts are generated around
+// them.
+func setBreakpointLayouts(widgets []Widget, bp Breakpoint, positions map[int]WidgetPosition) error {
+	projection := make([]Widget, len(widgets))
+	for i, widget := range widgets {
+		projection[i] = Widget{Id: widget.Id}
+		if layout, ok := widget.Layouts[bp.Name]; ok {
+			projection[i].X, projection[i].Y, projection[i].W, projection[i].H = &layout.X, &layout.Y, &layout.W, &layout.H
+		}
+	}
+	moved := []int{}
+	for index, position := range positions {
+		projection[index].X, projection[index].Y, projection[index].W, projection[index].H = position.X, position.Y, position.W, position.H
+		moved = append(moved, index)
+	}
+	err := bp.grid().arrangeMovedWidgets(projection, moved)
+	if err != nil {
+		return err
+	}
+	for i := range widgets {
+		if r, ok := widgetRect(projection[i]); ok {
+			if widgets[i].Layouts == nil {
+				widgets[i].Layouts = map[string]BreakpointLayout{}
+			}
+			widgets[i].Layouts[bp.Name] = BreakpointLayout{X: r.x, Y: r.y, W: r.w, H: r.h}
+		}
+	}
+	return nil
+}
+
+// deriveLayouts generates layouts for all widgets without explicit layout for the breakpoint by scaling their
+// default layout to the column count of the breakpoint and moving them down until they no longer overlap.
+func deriveLayouts(widgets []Widget, bp Breakpoint) map[int]BreakpointLayout {
+	grid := bp.grid()
+	placed := []rect{}
+	pending := []int{}
+	for i, widget := range widgets {
+		if layout, ok := widget.Layouts[bp.Name]; ok {
+			placed = append(placed, layout.rect())
+		} else {
+			pending = append(pending, i)
+		}
+	}
+	sort.SliceStable(pending, func(a, b int) bool {
+		ra, okA := widgetRect(widgets[pending[a]])
+		rb, okB := widgetRect(widgets[pending[b]])
+		if okA != okB {
+			return okA
+		}
+		if ra.y != rb.y {
+			return ra.y < rb.y
+		}
+		return ra.x < rb.x
+	})
+
+	scale := float64(bp.Columns) / float64(Config.Grid.Columns)
+	result := map[int]BreakpointLayout{}
+	for _, i := range pending {
+		var r rect
+		if base, ok := widgetRect(widgets[i]); ok {
+			r.w = min(max(int(math.Round(float64(base.w)*scale)), grid.MinWidth, 1), grid.maxWidth())
+			r.x = max(min(int(math.Round(float64(base.x)*scale)), bp.Columns-r.w), 0)
+			r.y = base.y
+			r.h = base.h
+			for moved := true; moved; {
+				moved = false
+				for _, p := range placed {
+					if r.overlaps(p) {
+						r.y = p.y + p.h
+						moved = true
+					}
+				}
+			}
+		} else {
+			r.w = min(grid.DefaultWidth, grid.maxWidth())
+			r.h = grid.DefaultHeight
+			// r.w is limited to the columns of the breakpoint, which are positive
+			r.x, r.y, _ = grid.findFreeSlot(placed, r.w, r.h)
+		}
+		placed = append(placed, r)
+		result[i] = BreakpointLayout{X: r.x, Y: r.y, W: r.w, H: r.h, Derived: true}
+	}
+	return result
+}
+
+// withDerivedLayouts returns a copy of the dashboard where every widget has a layout for every configured breakpoint.
+func (this Dashboard) withDerivedLayouts() Dashboard {
+	if len(Config.Breakpoints) == 0 {
+		return this
+	}
+	widgets := make([]Widget, len(this.Widgets))
+	copy(widgets, this.Widgets)
+	for i := range widgets {
+		layouts := map[string]BreakpointLayout{}
+		for name, layout := range widgets[i].Layouts {
+			layouts[name] = layout
+		}
+		widgets[i].Layouts = layouts
+	}
+	for _, bp := range Config.Breakpoints {
+		for i, layout := range deriveLayouts(this.Widgets, bp) {
+			widgets[i].Layouts[bp.Name] = layout
+		}
+	}
+	this.Widgets = widgets
+	return this
+}
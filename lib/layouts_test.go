@@ -0,0 +1,63 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestDeriveLayouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		widgets []Widget
+		want    map[int]BreakpointLayout
+	}{
+		{
+			name:    "layout is scaled to the columns of the breakpoint",
+			widgets: testLayout(rect{x: 6, y: 0, w: 6, h: 2}),
+			want:    map[int]BreakpointLayout{0: {X: 3, Y: 0, W: 3, H: 2, Derived: true}},
+		},
+		{
+			name:    "overlapping widget is moved below",
+			widgets: testLayout(rect{x: 0, y: 0, w: 6, h: 300000000}, rect{x: 3, y: 1, w: 6, h: 2}),
+			want: map[int]BreakpointLayout{
+				0: {X: 0, Y: 0, W: 3, H: 300000000, Derived: true},
+				1: {X: 2, Y: 300000000, W: 3, H: 2, Derived: true},
+			},
+		},
+		{
+			name:    "widget without position is placed into the first free slot",
+			widgets: testLayout(rect{x: 0, y: 0, w: 12, h: 2}, rect{}),
+			want: map[int]BreakpointLayout{
+				0: {X: 0, Y: 0, W: 6, H: 2, Derived: true},
+				1: {X: 0, Y: 2, W: 4, H: 2, Derived: true},
+			},
+		},
+	}
+	grid := Config.Grid
+	Config.Grid = testGrid()
+	defer func() { Config.Grid = grid }()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := deriveLayouts(test.widgets, Breakpoint{Name: "sm", Columns: 6})
+			if !maps.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
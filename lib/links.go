@@ -0,0 +1,159 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ShareLink struct {
+	Id          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Token       string             `json:"token"`
+	DashboardId primitive.ObjectID `bson:"dashboardid" json:"dashboard_id"`
+	UserId      string             `json:"user_id,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"created_at"`
+	ExpiresAt   *time.Time         `bson:"expiresAt,omitempty" json:"expires_at,omitempty"`
+}
+
+type ShareLinkRequest struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (this *ShareLink) expired() bool {
+	return this.ExpiresAt != nil && !this.ExpiresAt.After(time.Now())
+}
+
+func newShareLinkToken() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func createShareLink(ctx context.Context, dashboardId string, req ShareLinkRequest, userId string) (link ShareLink, err error) {
+	dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleOwner)
+	if err != nil {
+		return link, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return link, errors.Join(ErrBadRequest, errors.New("expiry is in the past"))
+	}
+	token, err := newShareLinkToken()
+	if err != nil {
+		return link, errors.Join(ErrInternalServerError, err)
+	}
+	link = ShareLink{
+		Id:          primitive.NewObjectID(),
+		Token:       token,
+		DashboardId: dash.Id,
+		UserId:      userId,
+		CreatedAt:   time.Now(),
+		ExpiresAt:   req.ExpiresAt,
+	}
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		_, err := MongoShareLinks().InsertOne(ctx, link)
+		if err != nil {
+			log.Logger.Error("create share link failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		return recordAudit(ctx, AuditEntry{Action: AuditShareLinkCreated, ActorId: userId, DashboardId: dashboardId, LinkId: link.Id.Hex()})
+	})
+	if err != nil {
+		return ShareLink{}, err
+	}
+	return link, nil
+}
+
+func listShareLinks(ctx context.Context, dashboardId string, userId string) (links []ShareLink, err error) {
+	dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cur, err := MongoShareLinks().Find(ctx, bson.M{"dashboardid": dash.Id}, opts)
+	if err != nil {
+		log.Logger.Error("find share links failed", attributes.ErrorKey, err)
+		return nil, normalizeModelError(err)
+	}
+	links = []ShareLink{}
+	if err = cur.All(ctx, &links); err != nil {
+		return nil, normalizeModelError(err)
+	}
+	return links, nil
+}
+
+func deleteShareLink(ctx context.Context, dashboardId string, linkId string, userId string) error {
+	dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleOwner)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(linkId)
+	if err != nil {
+		return normalizeModelError(err)
+	}
+	return withTransaction(ctx, func(ctx context.Context) error {
+		result, err := MongoShareLinks().DeleteOne(ctx, bson.M{"_id": id, "dashboardid": dash.Id})
+		if err != nil {
+			log.Logger.Error("delete share link failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		if result.DeletedCount == 0 {
+			return errors.Join(ErrNotFound, errors.New("share link not found"))
+		}
+		return recordAudit(ctx, AuditEntry{Action: AuditShareLinkDeleted, ActorId: userId, DashboardId: dashboardId, LinkId: linkId})
+	})
+}
+
+func deleteShareLinksOfDashboard(ctx context.Context, dashboardId primitive.ObjectID) error {
+	_, err := MongoShareLinks().DeleteMany(ctx, bson.M{"dashboardid": dashboardId})
+	if err != nil {
+		log.Logger.Error("delete share links of dashboard failed", attributes.ErrorKey, err)
+		return normalizeModelError(err)
+	}
+	return nil
+}
+
+func getPublicDashboard(ctx context.Context, token string) (dash Dashboard, err error) {
+	var link ShareLink
+	err = MongoShareLinks().FindOne(ctx, bson.M{"token": token}).Decode(&link)
+	if err != nil {
+		return dash, normalizeModelError(err)
+	}
+	if link.expired() {
+		return dash, errors.Join(ErrNotFound, errors.New("share link expired"))
+	}
+	err = Mongo().FindOne(ctx, bson.M{"_id": link.DashboardId}).Decode(&dash)
+	if err != nil {
+		log.Logger.Error("find dashboard of share link failed", attributes.ErrorKey, err)
+		return dash, normalizeModelError(err)
+	}
+	dash.UserId = ""
+	dash.Shares = nil
+	return dash, nil
+}
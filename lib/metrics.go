@@ -0,0 +1,149 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const metricsNamespace = "dashboard"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	mongoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "mongo_operation_duration_seconds",
+		Help:      "Duration of the database operations by function.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"function"})
+	mongoErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "mongo_operation_errors_total",
+		Help:      "Number of failed database operations by function. Client errors like not found are not counted.",
+	}, []string{"function"})
+	defaultDashboardsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "default_dashboards_created_total",
+		Help:      "Number of default dashboards created for users without dashboards.",
+	})
+	migratedDocuments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "migrated_documents_total",
+		Help:      "Number of documents changed by migrations.",
+	}, []string{"migration"})
+	syncedDashboards = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "synced_dashboards_total",
+		Help:      "Number of dashboards copied from the standalone to the replica set database.",
+	})
+	rateLimitedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Number of requests rejected by the rate limit by budget.",
+	}, []string{"budget"})
+)
+
+func init() {
+	prometheus.MustRegister(&totalsCollector{
+		dashboards: prometheus.NewDesc(metricsNamespace+"_dashboards", "Number of stored dashboards.", nil, nil),
+		widgets:    prometheus.NewDesc(metricsNamespace+"_widgets", "Number of widgets in all dashboards.", nil, nil),
+	})
+}
+
+func metricsHandler(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+}
+
+// observeMongo records the duration and failure of a database function, use as
+// defer observeMongo("function", time.Now(), &err).
+func observeMongo(function string, start time.Time, err *error) {
+	mongoDuration.WithLabelValues(function).Observe(time.Since(start).Seconds())
+	if *err != nil && GetStatusCode(*err) >= 500 {
+		mongoErrors.WithLabelValues(function).Inc()
+	}
+}
+
+// totalsCollector counts dashboards and widgets on every scrape.
+type totalsCollector struct {
+	dashboards *prometheus.Desc
+	widgets    *prometheus.Desc
+}
+
+func (this *totalsCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- this.dashboards
+	descs <- this.widgets
+}
+
+func (this *totalsCollector) Collect(metrics chan<- prometheus.Metric) {
+	if DB == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cur, err := Mongo().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":        nil,
+			"dashboards": bson.M{"$sum": 1},
+			"widgets":    bson.M{"$sum": bson.M{"$size": bson.M{"$ifNull": bson.A{"$widgets", bson.A{}}}}},
+		}}},
+	})
+	if err != nil {
+		log.Logger.Error("count dashboards for metrics failed", attributes.ErrorKey, err)
+		return
+	}
+	var totals []struct {
+		Dashboards int64 `bson:"dashboards"`
+		Widgets    int64 `bson:"widgets"`
+	}
+	if err = cur.All(ctx, &totals); err != nil {
+		log.Logger.Error("decode dashboard totals failed", attributes.ErrorKey, err)
+		return
+	}
+	var dashboards, widgets int64
+	if len(totals) > 0 {
+		dashboards, widgets = totals[0].Dashboards, totals[0].Widgets
+	}
+	metrics <- prometheus.MustNewConstMetric(this.dashboards, prometheus.GaugeValue, float64(dashboards))
+	metrics <- prometheus.MustNewConstMetric(this.widgets, prometheus.GaugeValue, float64(widgets))
+}
@@ -23,7 +23,9 @@ import (
 	"time"
 
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/SENERGY-Platform/dashboard/lib/log"
@@ -34,14 +36,37 @@ type Response struct {
 	Message string `json:"message,omitempty"`
 }
 
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
 type Dashboard struct {
-	Id          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name        string             `json:"name,omitempty"`
-	UserId      string             `json:"user_id,omitempty"`
-	RefreshTime uint16             `json:"refresh_time"`
-	Widgets     []Widget           `json:"widgets"`
-	Index       *uint16            `json:"index,omitempty"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt,omitempty"`
+	Id          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name        string              `json:"name,omitempty"`
+	UserId      string              `json:"user_id,omitempty"`
+	RefreshTime uint16              `json:"refresh_time"`
+	Widgets     []Widget            `json:"widgets"`
+	Index       *uint16             `json:"index,omitempty"`
+	UpdatedAt   time.Time           `bson:"updatedAt" json:"updatedAt,omitempty"`
+	Shares      []DashboardShare    `json:"shares,omitempty"`
+	Shared      bool                `bson:"-" json:"shared,omitempty"`
+	Variables   []DashboardVariable `json:"variables,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	FolderId    string              `bson:"folderid" json:"folder_id,omitempty"`
+	FolderIndex *uint16             `bson:"folderindex" json:"folder_index,omitempty"`
+}
+
+type DashboardShare struct {
+	UserId string `json:"user_id"`
+	Role   string `json:"role" enums:"viewer,editor"`
 }
 
 type Widget struct {
@@ -53,6 +78,8 @@ type Widget struct {
 	Name       string             `json:"name,omitempty"`
 	Type       string             `json:"type,omitempty"`
 	Properties interface{}        `json:"properties,omitempty"`
+	// Layouts holds the layout per breakpoint name, X, Y, W and H are the default layout.
+	Layouts map[string]BreakpointLayout `json:"layouts,omitempty"`
 }
 
 type WidgetPosition struct {
@@ -63,6 +90,82 @@ type WidgetPosition struct {
 	H                    *int               `json:"h,omitempty"`
 	DashboardOrigin      string             `json:"dashboardOrigin"`
 	DashboardDestination string             `json:"dashboardDestination"`
+	// Breakpoint selects the breakpoint layout to update instead of the default layout.
+	Breakpoint string `json:"breakpoint,omitempty"`
+}
+
+type WidgetLayout struct {
+	Id primitive.ObjectID `json:"id"`
+	X  *int               `json:"x,omitempty"`
+	Y  *int               `json:"y,omitempty"`
+	W  *int               `json:"w,omitempty"`
+	H  *int               `json:"h,omitempty"`
+}
+
+type WidgetSearchResult struct {
+	DashboardId   primitive.ObjectID `bson:"dashboard_id" json:"dashboard_id"`
+	DashboardName string             `bson:"dashboard_name" json:"dashboard_name"`
+	Widget        Widget             `bson:"widget" json:"widget"`
+}
+
+func (this *Dashboard) layouts() []WidgetLayout {
+	result := []WidgetLayout{}
+	for _, widget := range this.Widgets {
+		result = append(result, WidgetLayout{Id: widget.Id, X: widget.X, Y: widget.Y, W: widget.W, H: widget.H})
+	}
+	return result
+}
+
+func (this *Dashboard) roleOf(userId string) string {
+	if this.UserId == userId {
+		return RoleOwner
+	}
+	for _, share := range this.Shares {
+		if share.UserId == userId {
+			return share.Role
+		}
+	}
+	return ""
+}
+
+func (this *Dashboard) checkAccess(userId string, role string) error {
+	if roleRanks[this.roleOf(userId)] < roleRanks[role] {
+		return errors.Join(ErrForbidden, fmt.Errorf("dashboard requires role %s", role))
+	}
+	return nil
+}
+
+// present prepares a dashboard for the response to the given user.
+func (this Dashboard) present(userId string) Dashboard {
+	return this.visibleTo(userId).withDerivedLayouts()
+}
+
+// visibleTo hides the share list from users that do not own the dashboard.
+func (this Dashboard) visibleTo(userId string) Dashboard {
+	if this.UserId != userId {
+		this.Shares = nil
+	}
+	return this
+}
+
+func validateShares(shares []DashboardShare, ownerId string) error {
+	seen := map[string]bool{}
+	for _, share := range shares {
+		if share.UserId == "" {
+			return errors.Join(ErrBadRequest, errors.New("share user id is empty"))
+		}
+		if share.UserId == ownerId {
+			return errors.Join(ErrBadRequest, errors.New("dashboard can not be shared with its owner"))
+		}
+		if share.Role != RoleViewer && share.Role != RoleEditor {
+			return errors.Join(ErrBadRequest, fmt.Errorf("invalid share role %s", share.Role))
+		}
+		if seen[share.UserId] {
+			return errors.Join(ErrBadRequest, fmt.Errorf("duplicate share for user %s", share.UserId))
+		}
+		seen[share.UserId] = true
+	}
+	return nil
 }
 
 func (this *Dashboard) GetWidget(id primitive.ObjectID) (index int, result Widget, err error) {
@@ -149,6 +252,54 @@ func (this *Dashboard) updateWidget(newValue interface{}, propertyToChange strin
 	return nil
 }
 
+// clone copies the widget and share lists including the properties and layouts of the widgets, so the copy keeps
+// its state while the original is changed.
+func (this Dashboard) clone() Dashboard {
+	this.Widgets = slices.Clone(this.Widgets)
+	for i := range this.Widgets {
+		if this.Widgets[i].Properties != nil {
+			this.Widgets[i].Properties = deepCopy(reflect.ValueOf(this.Widgets[i].Properties)).Interface()
+		}
+		this.Widgets[i].Layouts = maps.Clone(this.Widgets[i].Layouts)
+	}
+	this.Shares = slices.Clone(this.Shares)
+	return this
+}
+
+// deepCopy copies maps and slices recursively and keeps their types, other values are returned as they are.
+func deepCopy(val reflect.Value) reflect.Value {
+	switch val.Kind() {
+	case reflect.Interface:
+		if val.IsNil() {
+			return val
+		}
+		result := reflect.New(val.Type()).Elem()
+		result.Set(deepCopy(val.Elem()))
+		return result
+	case reflect.Map:
+		if val.IsNil() {
+			return val
+		}
+		result := reflect.MakeMapWithSize(val.Type(), val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return result
+	case reflect.Slice:
+		if val.IsNil() {
+			return val
+		}
+		result := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+		for i := 0; i < val.Len(); i++ {
+			result.Index(i).Set(deepCopy(val.Index(i)))
+		}
+		return result
+	default:
+		return val
+	}
+}
+
 func (this *Dashboard) NewIndexIsInValid(index int) bool {
 	return index > len(this.Widgets) || index < 0 // widget can also be appened -> index > len()
 }
@@ -0,0 +1,270 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	OutboxSinkKafka  = "kafka"
+	OutboxSinkFile   = "file"
+	OutboxSinkStdout = "stdout"
+)
+
+// outboxLeaseDuration is how long a relay may publish without renewing its lease. Only the lease holder publishes,
+// so events of a dashboard are published in order even with several instances. The lease is renewed before each batch.
+const outboxLeaseDuration = 30 * time.Second
+
+type OutboxConfig struct {
+	// Sink is one of OutboxSinkKafka, OutboxSinkFile and OutboxSinkStdout. No events are written if empty.
+	Sink         string
+	File         string
+	KafkaBrokers []string
+	KafkaTopic   string
+	PollInterval time.Duration
+	BatchSize    int
+	// Retention is how long published events are kept in the outbox.
+	Retention time.Duration
+}
+
+// OutboxEvent is the domain event published to other services. Seq orders the events of a dashboard, consumers
+// have to expect duplicates.
+type OutboxEvent struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	Type        string             `json:"type"`
+	DashboardId string             `json:"dashboard_id"`
+	WidgetId    string             `json:"widget_id,omitempty"`
+	ActorId     string             `json:"actor_id,omitempty"`
+	// UserIds are the owner and the users the dashboard is shared with.
+	UserIds     []string   `json:"user_ids"`
+	Seq         int64      `json:"seq"`
+	Time        time.Time  `json:"time"`
+	PublishedAt *time.Time `bson:"publishedat,omitempty" json:"-"`
+}
+
+// OutboxSink publishes outbox events. Publish has to keep the order of the events of a dashboard.
+type OutboxSink interface {
+	Publish(ctx context.Context, events []OutboxEvent) error
+	Close() error
+}
+
+func writeOutboxEvent(ctx context.Context, event Event) error {
+	if Config.Outbox.Sink == "" {
+		return nil
+	}
+	var sequence struct {
+		Seq int64 `bson:"seq"`
+	}
+	// concurrent transactions of a dashboard conflict on the sequence, so its order is the commit order
+	err := MongoOutboxSequences().FindOneAndUpdate(ctx, bson.M{"_id": event.DashboardId}, bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&sequence)
+	if err != nil {
+		log.Logger.Error("increment outbox sequence failed", attributes.ErrorKey, err)
+		return normalizeModelError(err)
+	}
+	_, err = MongoOutbox().InsertOne(ctx, OutboxEvent{
+		Id:          primitive.NewObjectID(),
+		Type:        event.Type,
+		DashboardId: event.DashboardId,
+		WidgetId:    event.WidgetId,
+		ActorId:     event.ActorId,
+		UserIds:     event.Recipients,
+		Seq:         sequence.Seq,
+		Time:        event.Time,
+	})
+	if err != nil {
+		log.Logger.Error("write outbox event failed", attributes.ErrorKey, err)
+		return normalizeModelError(err)
+	}
+	return nil
+}
+
+func newOutboxSink(config OutboxConfig) (OutboxSink, error) {
+	switch config.Sink {
+	case OutboxSinkKafka:
+		return newKafkaSink(config)
+	case OutboxSinkFile:
+		file, err := os.OpenFile(config.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return &writerSink{writer: file, closer: file}, nil
+	case OutboxSinkStdout:
+		return &writerSink{writer: os.Stdout}, nil
+	default:
+		return nil, errors.New("unknown outbox sink: " + config.Sink)
+	}
+}
+
+// writerSink writes one JSON document per line, for local testing.
+type writerSink struct {
+	writer io.Writer
+	closer io.Closer
+}
+
+func (this *writerSink) Publish(_ context.Context, events []OutboxEvent) error {
+	encoder := json.NewEncoder(this.writer)
+	for _, event := range events {
+		err := encoder.Encode(event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *writerSink) Close() error {
+	if this.closer == nil {
+		return nil
+	}
+	return this.closer.Close()
+}
+
+type outboxRelay struct {
+	id     string
+	sink   OutboxSink
+	config OutboxConfig
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+var relay *outboxRelay
+
+// StartOutboxRelay starts publishing the outbox to the configured sink. Does nothing if no sink is configured.
+func StartOutboxRelay() {
+	if Config.Outbox.Sink == "" {
+		log.Logger.Info("no outbox sink configured, domain events are not published")
+		return
+	}
+	sink, err := newOutboxSink(Config.Outbox)
+	if err != nil {
+		panic("could not create outbox sink: " + err.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	relay = &outboxRelay{id: primitive.NewObjectID().Hex(), sink: sink, config: Config.Outbox, cancel: cancel}
+	relay.done.Add(1)
+	go relay.run(ctx)
+	log.Logger.Info("started outbox relay", "sink", Config.Outbox.Sink, "relay_id", relay.id)
+}
+
+// StopOutboxRelay stops the relay after the current batch and closes the sink.
+func StopOutboxRelay() {
+	if relay == nil {
+		return
+	}
+	relay.cancel()
+	relay.done.Wait()
+	err := relay.sink.Close()
+	if err != nil {
+		log.Logger.Error("close outbox sink failed", attributes.ErrorKey, err)
+	}
+	relay = nil
+}
+
+func (this *outboxRelay) run(ctx context.Context) {
+	defer this.done.Done()
+	ticker := time.NewTicker(this.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := this.relay(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Logger.Error("relay outbox failed", attributes.ErrorKey, err)
+			}
+		}
+	}
+}
+
+func (this *outboxRelay) relay(ctx context.Context) error {
+	for {
+		expires, leased, err := this.acquireLease(ctx)
+		if err != nil || !leased {
+			// the lease is held by another instance, possibly taken over after ours expired
+			return err
+		}
+		// unpublished events are sorted by dashboard and sequence to keep the order per dashboard
+		opts := options.Find().SetSort(bson.D{{Key: "dashboardid", Value: 1}, {Key: "seq", Value: 1}}).SetLimit(int64(this.config.BatchSize))
+		cur, err := MongoOutbox().Find(ctx, bson.M{"publishedat": nil}, opts)
+		if err != nil {
+			return err
+		}
+		var batch []OutboxEvent
+		if err = cur.All(ctx, &batch); err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		// the batch is not published after the lease expires, another instance may publish newer events by then
+		publishCtx, cancel := context.WithDeadline(ctx, expires)
+		err = this.sink.Publish(publishCtx, batch)
+		cancel()
+		if err != nil {
+			// nothing is marked as published, the batch is retried with the next poll
+			return err
+		}
+		ids := make([]primitive.ObjectID, len(batch))
+		for i, event := range batch {
+			ids[i] = event.Id
+		}
+		_, err = MongoOutbox().UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"publishedat": time.Now()}})
+		if err != nil {
+			return err
+		}
+		if len(batch) < this.config.BatchSize {
+			break
+		}
+	}
+	if this.config.Retention > 0 {
+		_, err := MongoOutbox().DeleteMany(ctx, bson.M{"publishedat": bson.M{"$lt": time.Now().Add(-this.config.Retention)}})
+		return err
+	}
+	return nil
+}
+
+// acquireLease takes the relay lease if it is free or expired, or renews it if this relay holds it already.
+// Returns when the lease expires.
+func (this *outboxRelay) acquireLease(ctx context.Context) (expires time.Time, leased bool, err error) {
+	now := time.Now()
+	expires = now.Add(outboxLeaseDuration)
+	_, err = MongoOutboxLeases().UpdateOne(ctx,
+		bson.M{"_id": "relay", "$or": bson.A{bson.M{"owner": this.id}, bson.M{"expires": bson.M{"$lt": now}}}},
+		bson.M{"$set": bson.M{"owner": this.id, "expires": expires}},
+		options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// held by another instance
+		return expires, false, nil
+	}
+	return expires, err == nil, err
+}
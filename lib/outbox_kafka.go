@@ -0,0 +1,64 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaSink publishes events keyed by dashboard id, so all events of a dashboard end up in the same partition.
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func newKafkaSink(config OutboxConfig) (OutboxSink, error) {
+	if len(config.KafkaBrokers) == 0 || config.KafkaTopic == "" {
+		return nil, errors.New("kafka sink needs brokers and topic")
+	}
+	return &kafkaSink{writer: &kafka.Writer{
+		Addr:                   kafka.TCP(config.KafkaBrokers...),
+		Topic:                  config.KafkaTopic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		BatchSize:              config.BatchSize,
+		AllowAutoTopicCreation: true,
+	}}, nil
+}
+
+func (this *kafkaSink) Publish(ctx context.Context, events []OutboxEvent) error {
+	messages := make([]kafka.Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages[i] = kafka.Message{
+			Key:     []byte(event.DashboardId),
+			Value:   value,
+			Headers: []kafka.Header{{Key: "type", Value: []byte(event.Type)}},
+		}
+	}
+	return this.writer.WriteMessages(ctx, messages...)
+}
+
+func (this *kafkaSink) Close() error {
+	return this.writer.Close()
+}
@@ -0,0 +1,124 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Preferences are stored once per user, referenced dashboards have to be readable by the user.
+type Preferences struct {
+	UserId                string    `bson:"_id" json:"-"`
+	HomeDashboardId       string    `bson:"homedashboardid" json:"home_dashboard_id"`
+	FavoriteDashboardIds  []string  `bson:"favoritedashboardids" json:"favorite_dashboard_ids"`
+	DefaultRefreshTime    *uint16   `bson:"defaultrefreshtime" json:"default_refresh_time,omitempty"`
+	LastOpenedDashboardId string    `bson:"lastopeneddashboardid" json:"last_opened_dashboard_id"`
+	UpdatedAt             time.Time `bson:"updatedAt" json:"updatedAt,omitempty"`
+}
+
+func getPreferences(ctx context.Context, userId string) (prefs Preferences, err error) {
+	err = MongoPreferences().FindOne(ctx, bson.M{"_id": userId}).Decode(&prefs)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Preferences{UserId: userId, FavoriteDashboardIds: []string{}}, nil
+	}
+	if err != nil {
+		log.Logger.Error("find preferences failed", attributes.ErrorKey, err)
+		return prefs, normalizeModelError(err)
+	}
+	if prefs.FavoriteDashboardIds == nil {
+		prefs.FavoriteDashboardIds = []string{}
+	}
+	return prefs, nil
+}
+
+func updatePreferences(ctx context.Context, prefs Preferences, userId string) (Preferences, error) {
+	if prefs.FavoriteDashboardIds == nil {
+		prefs.FavoriteDashboardIds = []string{}
+	}
+	ids := append([]string{prefs.HomeDashboardId, prefs.LastOpenedDashboardId}, prefs.FavoriteDashboardIds...)
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		err := checkDashboardReadable(ctx, id, userId)
+		if err != nil {
+			return prefs, err
+		}
+	}
+	prefs.UserId = userId
+	prefs.UpdatedAt = time.Now()
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		_, err := MongoPreferences().ReplaceOne(ctx, bson.M{"_id": userId}, prefs, options.Replace().SetUpsert(true))
+		if err != nil {
+			log.Logger.Error("update preferences failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		return recordAudit(ctx, AuditEntry{Action: AuditPreferencesUpdated, ActorId: userId})
+	})
+	return prefs, err
+}
+
+func checkDashboardReadable(ctx context.Context, id string, userId string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.Join(ErrUnprocessableEntity, errors.New("invalid dashboard id "+id))
+	}
+	count, err := Mongo().CountDocuments(ctx, readableDashboardFilter(objectId, userId))
+	if err != nil {
+		return normalizeModelError(err)
+	}
+	if count == 0 {
+		return errors.Join(ErrUnprocessableEntity, errors.New("dashboard "+id+" not found"))
+	}
+	return nil
+}
+
+// removeDashboardFromPreferences drops a dashboard from the preferences of the users, of all users if userIds is nil,
+// once they can no longer read it.
+func removeDashboardFromPreferences(ctx context.Context, dashboardId string, userIds []string) error {
+	now := time.Now()
+	updates := []struct {
+		filter bson.M
+		update bson.M
+	}{
+		{bson.M{"homedashboardid": dashboardId}, bson.M{"$set": bson.M{"homedashboardid": "", "updatedAt": now}}},
+		{bson.M{"lastopeneddashboardid": dashboardId}, bson.M{"$set": bson.M{"lastopeneddashboardid": "", "updatedAt": now}}},
+		{bson.M{"favoritedashboardids": dashboardId}, bson.M{"$pull": bson.M{"favoritedashboardids": dashboardId}, "$set": bson.M{"updatedAt": now}}},
+	}
+	for _, u := range updates {
+		if userIds != nil {
+			u.filter["_id"] = bson.M{"$in": userIds}
+		}
+		_, err := MongoPreferences().UpdateMany(ctx, u.filter, u.update)
+		if err != nil {
+			log.Logger.Error("remove dashboard from preferences failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+	}
+	return nil
+}
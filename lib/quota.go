@@ -0,0 +1,175 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Quota limits the resources of a single user. A limit of 0 disables the check.
+type Quota struct {
+	MaxDashboardsPerUser   int `json:"max_dashboards_per_user"`
+	MaxWidgetsPerDashboard int `json:"max_widgets_per_dashboard"`
+	MaxPropertiesSize      int `json:"max_properties_size"`
+	MaxPropertiesDepth     int `json:"max_properties_depth"`
+	MaxRequestBodySize     int `json:"max_request_body_size"`
+	MaxBatchOperations     int `json:"max_batch_operations"`
+}
+
+type QuotaUsage struct {
+	Dashboards          int64          `json:"dashboards"`
+	WidgetsPerDashboard map[string]int `json:"widgets_per_dashboard"`
+}
+
+type QuotaStatus struct {
+	Limits Quota      `json:"limits"`
+	Usage  QuotaUsage `json:"usage"`
+}
+
+func checkDashboardQuota(ctx context.Context, userId string) error {
+	limit := Config.Quota.MaxDashboardsPerUser
+	if limit <= 0 {
+		return nil
+	}
+	count, err := Mongo().CountDocuments(ctx, bson.M{"userid": userId})
+	if err != nil {
+		return normalizeModelError(err)
+	}
+	if count >= int64(limit) {
+		return errors.Join(ErrUnprocessableEntity, fmt.Errorf("quota exceeded: max %d dashboards per user", limit))
+	}
+	return nil
+}
+
+func checkWidgetCount(count int) error {
+	limit := Config.Quota.MaxWidgetsPerDashboard
+	if limit > 0 && count > limit {
+		return errors.Join(ErrUnprocessableEntity, fmt.Errorf("quota exceeded: max %d widgets per dashboard", limit))
+	}
+	return nil
+}
+
+func checkWidgetProperties(properties interface{}) error {
+	if limit := Config.Quota.MaxPropertiesSize; limit > 0 {
+		encoded, err := json.Marshal(properties)
+		if err != nil {
+			return errors.Join(ErrBadRequest, err)
+		}
+		if len(encoded) > limit {
+			return errors.Join(ErrPayloadTooLarge, fmt.Errorf("widget properties exceed limit of %d bytes", limit))
+		}
+	}
+	if limit := Config.Quota.MaxPropertiesDepth; limit > 0 && valueDepth(reflect.ValueOf(properties)) > limit {
+		return errors.Join(ErrUnprocessableEntity, fmt.Errorf("widget properties exceed max nesting depth of %d", limit))
+	}
+	return nil
+}
+
+func checkDashboardWidgets(widgets []Widget) error {
+	err := checkWidgetCount(len(widgets))
+	if err != nil {
+		return err
+	}
+	for _, widget := range widgets {
+		err = checkWidgetProperties(widget.Properties)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valueDepth returns the nesting depth of maps and slices, scalars have a depth of 0.
+func valueDepth(val reflect.Value) int {
+	for val.Kind() == reflect.Interface || val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return 0
+		}
+		val = val.Elem()
+	}
+	depth := 0
+	switch val.Kind() {
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			depth = max(depth, valueDepth(iter.Value()))
+		}
+	case reflect.Slice, reflect.Array:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return 0
+		}
+		for i := 0; i < val.Len(); i++ {
+			depth = max(depth, valueDepth(val.Index(i)))
+		}
+	default:
+		return 0
+	}
+	return depth + 1
+}
+
+func getQuotaStatus(ctx context.Context, userId string) (result QuotaStatus, err error) {
+	result.Limits = Config.Quota
+	result.Usage.WidgetsPerDashboard = map[string]int{}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userid": userId}}},
+		{{Key: "$project", Value: bson.M{"widgets": bson.M{"$size": bson.M{"$ifNull": bson.A{"$widgets", bson.A{}}}}}}},
+	}
+	cur, err := Mongo().Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Logger.Error("read quota usage failed", attributes.ErrorKey, err)
+		return result, normalizeModelError(err)
+	}
+	var usage []struct {
+		Id      primitive.ObjectID `bson:"_id"`
+		Widgets int                `bson:"widgets"`
+	}
+	if err = cur.All(ctx, &usage); err != nil {
+		return result, normalizeModelError(err)
+	}
+	for _, dash := range usage {
+		result.Usage.Dashboards++
+		result.Usage.WidgetsPerDashboard[dash.Id.Hex()] = dash.Widgets
+	}
+	return result, nil
+}
+
+// bodySizeHandler rejects request bodies larger than Quota.MaxRequestBodySize.
+func bodySizeHandler(c *gin.Context) {
+	limit := int64(Config.Quota.MaxRequestBodySize)
+	if limit <= 0 {
+		c.Next()
+		return
+	}
+	if c.Request.ContentLength > limit {
+		abortWithError(c, errors.Join(ErrPayloadTooLarge, fmt.Errorf("request body exceeds limit of %d bytes", limit)))
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	c.Next()
+}
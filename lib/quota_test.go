@@ -0,0 +1,120 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCheckDashboardWidgets(t *testing.T) {
+	original := Config
+	defer func() { Config = original }()
+	Config.Quota = Quota{MaxWidgetsPerDashboard: 2, MaxPropertiesSize: 32, MaxPropertiesDepth: 2}
+
+	tests := []struct {
+		name    string
+		widgets []Widget
+		wantErr error
+	}{
+		{name: "within limits", widgets: []Widget{{Properties: map[string]interface{}{"a": []interface{}{1}}}, {}}},
+		{name: "too many widgets", widgets: []Widget{{}, {}, {}}, wantErr: ErrUnprocessableEntity},
+		{name: "properties too large", widgets: []Widget{{Properties: map[string]interface{}{"title": strings.Repeat("x", 32)}}}, wantErr: ErrPayloadTooLarge},
+		{name: "properties too deep", widgets: []Widget{{Properties: map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{}}}}}, wantErr: ErrUnprocessableEntity},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkDashboardWidgets(test.widgets)
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValueDepth(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  int
+	}{
+		{name: "nil", value: nil, want: 0},
+		{name: "scalar", value: "text", want: 0},
+		{name: "bytes", value: []byte("text"), want: 0},
+		{name: "empty map", value: map[string]interface{}{}, want: 1},
+		{name: "nested", value: map[string]interface{}{"a": 1, "b": []interface{}{map[string]interface{}{"c": 1}}}, want: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := valueDepth(reflect.ValueOf(test.value)); got != test.want {
+				t.Errorf("got depth %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBodySizeHandler(t *testing.T) {
+	original := Config
+	defer func() { Config = original }()
+	Config.Quota = Quota{MaxRequestBodySize: 8}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(bodySizeHandler)
+	router.POST("/", func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			abortWithError(c, bindError(err))
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		body     string
+		chunked  bool
+		wantCode int
+	}{
+		{name: "within limit", body: `{"a":1}`, wantCode: http.StatusOK},
+		{name: "invalid body within limit", body: `{"a"`, wantCode: http.StatusBadRequest},
+		{name: "content length above limit", body: `{"a":"long"}`, wantCode: http.StatusRequestEntityTooLarge},
+		{name: "chunked body above limit", body: `{"a":"long"}`, chunked: true, wantCode: http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			if test.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != test.wantCode {
+				t.Errorf("got status %d, want %d", rec.Code, test.wantCode)
+			}
+		})
+	}
+}
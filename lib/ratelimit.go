@@ -0,0 +1,252 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	RateLimitStoreMemory = "memory"
+	// RateLimitStoreMongo shares the budgets between all instances.
+	RateLimitStoreMongo = "mongo"
+)
+
+const (
+	rateLimitBudgetRead  = "read"
+	rateLimitBudgetWrite = "write"
+)
+
+// rateLimitSweepInterval is how often the memory store drops buckets of idle users.
+const rateLimitSweepInterval = time.Minute
+
+const rateLimitTimeout = 2 * time.Second
+
+type RateLimitConfig struct {
+	Store string
+	// Read applies to GET, HEAD and OPTIONS requests, Write to all others.
+	Read  RateLimit
+	Write RateLimit
+}
+
+// RateLimit is a token bucket refilled with Rate tokens per second up to Burst tokens. A Rate of 0 disables the limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is the time until the next token is available.
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+}
+
+// RateLimitStore takes cost tokens from the bucket of key, creating a full bucket if it does not exist. Nothing is
+// taken if the bucket holds less than cost tokens.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit, cost int) (RateLimitResult, error)
+}
+
+var rateLimits RateLimitStore
+
+// InitRateLimits creates the configured store. Call after InitDB.
+func InitRateLimits() {
+	switch Config.RateLimit.Store {
+	case RateLimitStoreMemory:
+		rateLimits = newMemoryRateLimitStore()
+	case RateLimitStoreMongo:
+		rateLimits = mongoRateLimitStore{}
+	default:
+		panic("unknown rate limit store " + Config.RateLimit.Store)
+	}
+}
+
+func newRateLimitResult(limit RateLimit, tokens float64, cost int, allowed bool) RateLimitResult {
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second)),
+	}
+	if tokens < float64(cost) {
+		result.RetryAfter = time.Duration((float64(cost) - tokens) / limit.Rate * float64(time.Second))
+	}
+	return result
+}
+
+// rateLimitHandler limits the requests per user, or per client ip for unauthenticated routes. Requests are let
+// through if the store fails. Operations of a batch are charged with the batch request.
+func rateLimitHandler(c *gin.Context) {
+	if isBatchOperation(c) || takeRateLimit(c, 1) {
+		c.Next()
+	}
+}
+
+// takeRateLimit takes cost tokens from the budget of the request and aborts it if they are not available.
+func takeRateLimit(c *gin.Context, cost int) bool {
+	budget, limit := rateLimitBudgetWrite, Config.RateLimit.Write
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		budget, limit = rateLimitBudgetRead, Config.RateLimit.Read
+	}
+	if rateLimits == nil || limit.Rate <= 0 || cost <= 0 {
+		return true
+	}
+	key := getUserId(c)
+	if key == "" {
+		key = "ip:" + c.ClientIP()
+	}
+	// a canceled request still takes its tokens
+	ctx, cancel := context.WithTimeout(context.Background(), rateLimitTimeout)
+	defer cancel()
+	result, err := rateLimits.Take(ctx, budget+":"+key, limit, cost)
+	if err != nil {
+		log.Logger.Warn("rate limit failed, allowing request", attributes.ErrorKey, err)
+		return true
+	}
+	header := c.Writer.Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+	if !result.Allowed {
+		rateLimitedRequests.WithLabelValues(budget).Inc()
+		header.Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+		abortWithError(c, errors.Join(ErrTooManyRequests, fmt.Errorf("%s rate limit exceeded", budget)))
+		return false
+	}
+	return true
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func (this *tokenBucket) refill(limit RateLimit, now time.Time) {
+	this.tokens = math.Min(float64(limit.Burst), this.tokens+now.Sub(this.updated).Seconds()*limit.Rate)
+	this.updated = now
+}
+
+type memoryRateLimitStore struct {
+	mux       sync.Mutex
+	buckets   map[string]*tokenBucket
+	limits    map[string]RateLimit
+	lastSweep time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: map[string]*tokenBucket{}, limits: map[string]RateLimit{}, lastSweep: time.Now()}
+}
+
+func (this *memoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit, cost int) (RateLimitResult, error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	if now.Sub(this.lastSweep) > rateLimitSweepInterval {
+		this.sweep(now)
+	}
+	bucket, ok := this.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), updated: now}
+		this.buckets[key] = bucket
+		this.limits[key] = limit
+	}
+	bucket.refill(limit, now)
+	allowed := bucket.tokens >= float64(cost)
+	if allowed {
+		bucket.tokens -= float64(cost)
+	}
+	return newRateLimitResult(limit, bucket.tokens, cost, allowed), nil
+}
+
+// sweep drops full buckets, they are recreated identically on the next request.
+func (this *memoryRateLimitStore) sweep(now time.Time) {
+	for key, bucket := range this.buckets {
+		limit := this.limits[key]
+		bucket.refill(limit, now)
+		if bucket.tokens >= float64(limit.Burst) {
+			delete(this.buckets, key)
+			delete(this.limits, key)
+		}
+	}
+	this.lastSweep = now
+}
+
+func MongoRateLimits() *mongo.Collection {
+	return DB.Database("dashboard").Collection("rate_limits")
+}
+
+// mongoRateLimitStore keeps the buckets in the database, refilled atomically with the database clock.
+type mongoRateLimitStore struct{}
+
+type mongoTokenBucket struct {
+	Tokens  float64 `bson:"tokens"`
+	Allowed bool    `bson:"allowed"`
+}
+
+func (this mongoRateLimitStore) Take(ctx context.Context, key string, limit RateLimit, cost int) (result RateLimitResult, err error) {
+	defer observeMongo("takeRateLimit", time.Now(), &err)
+	burst := float64(limit.Burst)
+	fillMillis := int64(burst / limit.Rate * 1000)
+	refilled := bson.M{"$min": bson.A{burst, bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$tokens", burst}},
+		bson.M{"$multiply": bson.A{
+			bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{"$$NOW", bson.M{"$ifNull": bson.A{"$updatedat", "$$NOW"}}}}, 1000}},
+			limit.Rate,
+		}},
+	}}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": refilled, "updatedat": "$$NOW"}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed":   bson.M{"$gte": bson.A{"$tokens", cost}},
+			"tokens":    bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$tokens", cost}}, bson.M{"$subtract": bson.A{"$tokens", cost}}, "$tokens"}},
+			"expiresat": bson.M{"$add": bson.A{"$$NOW", fillMillis}},
+		}}},
+	}
+	bucket := mongoTokenBucket{}
+	err = MongoRateLimits().FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&bucket)
+	if err != nil {
+		return result, err
+	}
+	return newRateLimitResult(limit, bucket.Tokens, cost, bucket.Allowed), nil
+}
+
+// createRateLimitIndices removes buckets once they are full again.
+func createRateLimitIndices(ctx context.Context) error {
+	_, err := MongoRateLimits().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresat", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
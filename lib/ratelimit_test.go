@@ -0,0 +1,116 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := newMemoryRateLimitStore()
+	limit := RateLimit{Rate: 0.001, Burst: 3}
+	tests := []struct {
+		name          string
+		cost          int
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{name: "full bucket", cost: 2, wantAllowed: true, wantRemaining: 1},
+		{name: "cost above the remaining tokens takes nothing", cost: 2, wantAllowed: false, wantRemaining: 1},
+		{name: "last token", cost: 1, wantAllowed: true, wantRemaining: 0},
+		{name: "empty bucket", cost: 1, wantAllowed: false, wantRemaining: 0},
+	}
+	for _, test := range tests {
+		result, err := store.Take(context.Background(), "write:user-1", limit, test.cost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != test.wantAllowed || result.Remaining != test.wantRemaining {
+			t.Fatalf("%s: got allowed %v with %d remaining, want %v with %d", test.name, result.Allowed, result.Remaining, test.wantAllowed, test.wantRemaining)
+		}
+		if !result.Allowed && result.RetryAfter <= 0 {
+			t.Errorf("%s: got no retry after", test.name)
+		}
+	}
+	result, err := store.Take(context.Background(), "write:user-2", limit, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed || result.Remaining != 2 {
+		t.Errorf("other key: got allowed %v with %d remaining, want a separate bucket", result.Allowed, result.Remaining)
+	}
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	original, originalStore := Config, rateLimits
+	defer func() { Config, rateLimits = original, originalStore }()
+	Config.RateLimit = RateLimitConfig{Read: RateLimit{Rate: 0.001, Burst: 1}, Write: RateLimit{Rate: 0.001, Burst: 2}}
+	rateLimits = newMemoryRateLimitStore()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(rateLimitHandler)
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name     string
+		method   string
+		ip       string
+		batch    bool
+		wantCode int
+	}{
+		{name: "first write", method: http.MethodPost, ip: "192.0.2.1", wantCode: http.StatusOK},
+		{name: "second write", method: http.MethodPost, ip: "192.0.2.1", wantCode: http.StatusOK},
+		{name: "write budget exhausted", method: http.MethodPost, ip: "192.0.2.1", wantCode: http.StatusTooManyRequests},
+		{name: "read budget is separate", method: http.MethodGet, ip: "192.0.2.1", wantCode: http.StatusOK},
+		{name: "read budget exhausted", method: http.MethodGet, ip: "192.0.2.1", wantCode: http.StatusTooManyRequests},
+		{name: "other client", method: http.MethodPost, ip: "192.0.2.2", wantCode: http.StatusOK},
+		{name: "batch operations are charged with the batch", method: http.MethodPost, ip: "192.0.2.1", batch: true, wantCode: http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/", nil)
+		req.RemoteAddr = test.ip + ":1234"
+		if test.batch {
+			req = req.WithContext(context.WithValue(req.Context(), batchContextKey{}, batchIdentity{userId: "user-1"}))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != test.wantCode {
+			t.Fatalf("%s: got status %d, want %d", test.name, rec.Code, test.wantCode)
+		}
+		if test.wantCode == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Errorf("%s: got no Retry-After header", test.name)
+		}
+	}
+}
+
+func TestNewRateLimitResult(t *testing.T) {
+	result := newRateLimitResult(RateLimit{Rate: 2, Burst: 10}, 4, 5, false)
+	if result.RetryAfter != 500*time.Millisecond {
+		t.Errorf("got retry after %v, want 500ms", result.RetryAfter)
+	}
+	if result.Reset != 3*time.Second {
+		t.Errorf("got reset %v, want 3s", result.Reset)
+	}
+}
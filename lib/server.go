@@ -0,0 +1,94 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+)
+
+type ServerConfig struct {
+	ListenAddress     string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout does not apply to event streams.
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	// TrustedProxies are the addresses or CIDR ranges of proxies whose X-Forwarded-For header is used for the client
+	// ip of rate limits and audit entries. If empty, the client ip is the address of the connection.
+	TrustedProxies []string
+}
+
+// Server runs the API server and, if configured, the separate metrics server.
+type Server struct {
+	servers []*http.Server
+}
+
+// shuttingDown fails the readiness check while the server drains.
+var shuttingDown atomic.Bool
+
+func newHttpServer(address string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadTimeout:       Config.Server.ReadTimeout,
+		ReadHeaderTimeout: Config.Server.ReadHeaderTimeout,
+		WriteTimeout:      Config.Server.WriteTimeout,
+		IdleTimeout:       Config.Server.IdleTimeout,
+	}
+}
+
+// Run serves until ctx is done or a server fails. Afterwards new connections are refused and in-flight requests are
+// drained within Config.Server.ShutdownTimeout.
+func (this *Server) Run(ctx context.Context) error {
+	failed := make(chan error, len(this.servers))
+	for _, server := range this.servers {
+		go func() {
+			log.Logger.Info("listen", "address", server.Addr)
+			err := server.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				failed <- err
+			}
+		}()
+	}
+	var err error
+	select {
+	case <-ctx.Done():
+		log.Logger.Info("shutting down server")
+	case err = <-failed:
+		log.Logger.Error("listen and serve failed", attributes.ErrorKey, err)
+	}
+	shuttingDown.Store(true)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), Config.Server.ShutdownTimeout)
+	defer cancel()
+	for _, server := range this.servers {
+		shutdownErr := server.Shutdown(shutdownCtx)
+		if shutdownErr != nil {
+			log.Logger.Error("server shutdown failed, closing remaining connections", attributes.ErrorKey, shutdownErr)
+			_ = server.Close()
+		}
+	}
+	log.Logger.Info("server stopped")
+	return err
+}
@@ -71,10 +71,11 @@ func Insert(dashs []interface{}) {
 	}
 
 	log.Logger.Info("insert dashboards into replicaset", "count", len(dashs))
-	_, err := replicaCollection.InsertMany(context.TODO(), dashs)
+	result, err := replicaCollection.InsertMany(context.TODO(), dashs)
 	if err != nil {
 		panic(err)
 	}
+	syncedDashboards.Add(float64(len(result.InsertedIDs)))
 }
 
 func Sync() {
@@ -0,0 +1,352 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// undoableEvents are the changes kept on the undo stack. Share and folder changes are not undoable.
+var undoableEvents = []string{EventWidgetAdded, EventWidgetChanged, EventWidgetMoved, EventWidgetRemoved, EventDashboardUpdated}
+
+type undoContextKey struct{}
+
+// UndoEntry holds the parts of a dashboard changed by a user, Before to undo and After to redo the change. The
+// entries of a user and dashboard form a stack ordered by Seq, undone entries are on top and removed by new changes.
+type UndoEntry struct {
+	Id          primitive.ObjectID `bson:"_id,omitempty"`
+	UserId      string
+	DashboardId string
+	Seq         int64
+	Type        string
+	WidgetId    string
+	Undone      bool
+	Time        time.Time
+	Before      undoState
+	After       undoState
+}
+
+type undoState struct {
+	// Metadata is nil if the metadata did not change.
+	Metadata *undoMetadata
+	Widgets  []undoWidget
+}
+
+type undoMetadata struct {
+	Name        string
+	RefreshTime uint16
+	Variables   []DashboardVariable
+	Tags        []string
+}
+
+type undoWidget struct {
+	Id    primitive.ObjectID
+	Index int
+	// Widget is nil if the widget did not exist.
+	Widget *Widget
+}
+
+type UndoResult struct {
+	// Type is the event type of the reverted or reapplied change.
+	Type      string    `json:"type"`
+	WidgetIds []string  `json:"widget_ids,omitempty"`
+	Metadata  bool      `json:"metadata"`
+	Dashboard Dashboard `json:"dashboard"`
+	CanUndo   bool      `json:"can_undo"`
+	CanRedo   bool      `json:"can_redo"`
+}
+
+func MongoUndo() *mongo.Collection {
+	return DB.Database("dashboard").Collection("undo")
+}
+
+func metadataOf(dash Dashboard) *undoMetadata {
+	return &undoMetadata{Name: dash.Name, RefreshTime: dash.RefreshTime, Variables: dash.Variables, Tags: dash.Tags}
+}
+
+// newUndoEntry returns the differences between before and after, nil if there are none.
+func newUndoEntry(eventType string, before Dashboard, after Dashboard, widgetId string, userId string) *UndoEntry {
+	entry := UndoEntry{
+		UserId:      userId,
+		DashboardId: after.Id.Hex(),
+		Type:        eventType,
+		WidgetId:    widgetId,
+		Time:        time.Now(),
+	}
+	if !reflect.DeepEqual(metadataOf(before), metadataOf(after)) {
+		entry.Before.Metadata = metadataOf(before)
+		entry.After.Metadata = metadataOf(after)
+	}
+	ids := []primitive.ObjectID{}
+	for _, widget := range append(slices.Clone(before.Widgets), after.Widgets...) {
+		if !slices.Contains(ids, widget.Id) {
+			ids = append(ids, widget.Id)
+		}
+	}
+	for _, id := range ids {
+		beforeWidget := findUndoWidget(before.Widgets, id)
+		afterWidget := findUndoWidget(after.Widgets, id)
+		if !reflect.DeepEqual(beforeWidget, afterWidget) {
+			entry.Before.Widgets = append(entry.Before.Widgets, beforeWidget)
+			entry.After.Widgets = append(entry.After.Widgets, afterWidget)
+		}
+	}
+	if entry.Before.Metadata == nil && len(entry.Before.Widgets) == 0 {
+		return nil
+	}
+	return &entry
+}
+
+func findUndoWidget(widgets []Widget, id primitive.ObjectID) undoWidget {
+	index := slices.IndexFunc(widgets, func(w Widget) bool {
+		return w.Id == id
+	})
+	if index < 0 {
+		return undoWidget{Id: id}
+	}
+	return undoWidget{Id: id, Index: index, Widget: &widgets[index]}
+}
+
+// apply restores the state, changes of other widgets since are kept.
+func (this undoState) apply(dash *Dashboard) {
+	if this.Metadata != nil {
+		dash.Name = this.Metadata.Name
+		dash.RefreshTime = this.Metadata.RefreshTime
+		dash.Variables = this.Metadata.Variables
+		dash.Tags = this.Metadata.Tags
+	}
+	for _, widget := range this.Widgets {
+		if widget.Widget == nil {
+			dash.Widgets = slices.DeleteFunc(dash.Widgets, func(w Widget) bool {
+				return w.Id == widget.Id
+			})
+		}
+	}
+	restored := slices.DeleteFunc(slices.Clone(this.Widgets), func(w undoWidget) bool {
+		return w.Widget == nil
+	})
+	slices.SortFunc(restored, func(a, b undoWidget) int {
+		return a.Index - b.Index
+	})
+	for _, widget := range restored {
+		index := slices.IndexFunc(dash.Widgets, func(w Widget) bool {
+			return w.Id == widget.Id
+		})
+		if index >= 0 {
+			dash.Widgets[index] = *widget.Widget
+		} else {
+			dash.Widgets = slices.Insert(dash.Widgets, min(widget.Index, len(dash.Widgets)), *widget.Widget)
+		}
+	}
+}
+
+// check runs the checks of createWidget, updateWidget and editDashboard on the dashboard the state was applied to
+// and handles the collisions of the restored widgets with the other widgets.
+func (this undoState) check(dash *Dashboard) error {
+	err := checkWidgetCount(len(dash.Widgets))
+	if err != nil {
+		return err
+	}
+	if this.Metadata != nil {
+		err = dash.validateDashboardVariables()
+		if err != nil {
+			return err
+		}
+	}
+	restored := []int{}
+	for _, widget := range this.Widgets {
+		index := slices.IndexFunc(dash.Widgets, func(w Widget) bool {
+			return w.Id == widget.Id
+		})
+		if widget.Widget == nil || index < 0 {
+			continue
+		}
+		err = checkWidgetProperties(dash.Widgets[index].Properties)
+		if err != nil {
+			return err
+		}
+		err = dash.checkVariableReferences(dash.Widgets[index])
+		if err != nil {
+			return err
+		}
+		err = validateWidget(dash.Widgets[index], dash.Variables)
+		if err != nil {
+			return err
+		}
+		restored = append(restored, index)
+	}
+	return Config.Grid.arrangeMovedWidgets(dash.Widgets, restored)
+}
+
+// undoEventType returns the event type of undoing a change of eventType.
+func undoEventType(eventType string) string {
+	switch eventType {
+	case EventWidgetAdded:
+		return EventWidgetRemoved
+	case EventWidgetRemoved:
+		return EventWidgetAdded
+	default:
+		return eventType
+	}
+}
+
+// recordUndo pushes the change onto the undo stack of the user and clears the redo entries. Changes made by undo and
+// redo and moves of widgets between dashboards are not recorded.
+func recordUndo(ctx context.Context, eventType string, before *Dashboard, after *Dashboard, widgetId string, userId string) error {
+	if Config.UndoStackSize <= 0 || ctx.Value(undoContextKey{}) != nil {
+		return nil
+	}
+	if eventType == EventDashboardDeleted {
+		_, err := MongoUndo().DeleteMany(ctx, bson.M{"dashboardid": before.Id.Hex()})
+		return normalizeModelError(err)
+	}
+	if before == nil || after == nil || userId == "" || !slices.Contains(undoableEvents, eventType) {
+		return nil
+	}
+	entry := newUndoEntry(eventType, *before, *after, widgetId, userId)
+	if entry == nil {
+		return nil
+	}
+	stack := bson.M{"userid": userId, "dashboardid": entry.DashboardId}
+	_, err := MongoUndo().DeleteMany(ctx, bson.M{"userid": userId, "dashboardid": entry.DashboardId, "undone": true})
+	if err != nil {
+		return normalizeModelError(err)
+	}
+	var top UndoEntry
+	err = MongoUndo().FindOne(ctx, stack, options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})).Decode(&top)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return normalizeModelError(err)
+	}
+	entry.Seq = top.Seq + 1
+	_, err = MongoUndo().InsertOne(ctx, entry)
+	if err != nil {
+		log.Logger.Error("write undo entry failed", attributes.ErrorKey, err)
+		return normalizeModelError(err)
+	}
+	_, err = MongoUndo().DeleteMany(ctx, bson.M{"userid": userId, "dashboardid": entry.DashboardId, "seq": bson.M{"$lte": entry.Seq - int64(Config.UndoStackSize)}})
+	return normalizeModelError(err)
+}
+
+// dropWidgetUndo removes the undo and redo entries of all users containing the widget from the stacks of the
+// dashboards. After a move between dashboards they would duplicate the widget or restore it on the wrong dashboard.
+func dropWidgetUndo(ctx context.Context, widgetId primitive.ObjectID, dashboardIds ...string) error {
+	_, err := MongoUndo().DeleteMany(ctx, bson.M{
+		"dashboardid": bson.M{"$in": dashboardIds},
+		"$or":         bson.A{bson.M{"before.widgets.id": widgetId}, bson.M{"after.widgets.id": widgetId}},
+	})
+	if err != nil {
+		log.Logger.Error("drop undo entries of moved widget failed", attributes.ErrorKey, err)
+	}
+	return normalizeModelError(err)
+}
+
+// undoDashboardChange reverts the latest change of the user to the dashboard, or with redo reapplies the latest
+// reverted change.
+func undoDashboardChange(ctx context.Context, dashboardId string, redo bool, userId string) (result UndoResult, err error) {
+	defer observeMongo("undoDashboardChange", time.Now(), &err)
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		dash, err := getDashboardWithRole(ctx, dashboardId, userId, RoleEditor)
+		if err != nil {
+			return err
+		}
+		var entry UndoEntry
+		opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+		if redo {
+			opts.SetSort(bson.D{{Key: "seq", Value: 1}})
+		}
+		err = MongoUndo().FindOne(ctx, bson.M{"userid": userId, "dashboardid": dashboardId, "undone": redo}, opts).Decode(&entry)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if redo {
+				return errors.Join(ErrConflict, errors.New("nothing to redo"))
+			}
+			return errors.Join(ErrConflict, errors.New("nothing to undo"))
+		}
+		if err != nil {
+			return normalizeModelError(err)
+		}
+
+		before := dash.clone()
+		state, eventType := entry.Before, undoEventType(entry.Type)
+		if redo {
+			state, eventType = entry.After, entry.Type
+		}
+		state.apply(&dash)
+		err = state.check(&dash)
+		if err != nil {
+			return err
+		}
+		dash.UpdatedAt = time.Now()
+		_, err = Mongo().UpdateOne(ctx, writableDashboardFilter(dash.Id, userId), bson.M{"$set": bson.M{
+			"name":        dash.Name,
+			"refreshtime": dash.RefreshTime,
+			"variables":   dash.Variables,
+			"tags":        dash.Tags,
+			"widgets":     dash.Widgets,
+			"updatedAt":   dash.UpdatedAt,
+		}})
+		if err != nil {
+			log.Logger.Error("undo dashboard change failed", attributes.ErrorKey, err)
+			return normalizeModelError(err)
+		}
+		err = recordDashboardChange(context.WithValue(ctx, undoContextKey{}, true), eventType, &before, &dash, entry.WidgetId, userId)
+		if err != nil {
+			return err
+		}
+		_, err = MongoUndo().UpdateByID(ctx, entry.Id, bson.M{"$set": bson.M{"undone": !redo}})
+		if err != nil {
+			return normalizeModelError(err)
+		}
+
+		result = UndoResult{Type: entry.Type, Metadata: state.Metadata != nil, Dashboard: dash}
+		for _, widget := range state.Widgets {
+			result.WidgetIds = append(result.WidgetIds, widget.Id.Hex())
+		}
+		undoable, err := MongoUndo().CountDocuments(ctx, bson.M{"userid": userId, "dashboardid": dashboardId, "undone": false})
+		if err != nil {
+			return normalizeModelError(err)
+		}
+		redoable, err := MongoUndo().CountDocuments(ctx, bson.M{"userid": userId, "dashboardid": dashboardId, "undone": true})
+		if err != nil {
+			return normalizeModelError(err)
+		}
+		result.CanUndo, result.CanRedo = undoable > 0, redoable > 0
+		return nil
+	})
+	if err != nil {
+		return UndoResult{}, err
+	}
+	return result, nil
+}
+
+func createUndoIndices(ctx context.Context) error {
+	_, err := MongoUndo().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userid", Value: 1}, {Key: "dashboardid", Value: 1}, {Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "dashboardid", Value: 1}}},
+	})
+	return err
+}
@@ -0,0 +1,210 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testWidgets returns widgets named after the given names. The first letter of a name identifies the widget, so "b"
+// and "b2" are two versions of the same widget.
+func testWidgets(names ...string) []Widget {
+	widgets := []Widget{}
+	for _, name := range names {
+		id := primitive.ObjectID{}
+		id[len(id)-1] = name[0]
+		widgets = append(widgets, Widget{Id: id, Name: name})
+	}
+	return widgets
+}
+
+func widgetNames(widgets []Widget) []string {
+	names := []string{}
+	for _, widget := range widgets {
+		names = append(names, widget.Name)
+	}
+	return names
+}
+
+func TestUndoStateApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  []string
+		after   []string
+		current []string
+		redo    bool
+		want    []string
+	}{
+		{
+			name:    "removed widget is restored at its index",
+			before:  []string{"a", "b", "c"},
+			after:   []string{"a", "c"},
+			current: []string{"a", "c"},
+			want:    []string{"a", "b", "c"},
+		},
+		{
+			name:    "removed widget is restored at its index after another widget was added",
+			before:  []string{"a", "b", "c"},
+			after:   []string{"a", "c"},
+			current: []string{"a", "c", "d"},
+			want:    []string{"a", "b", "c", "d"},
+		},
+		{
+			name:    "removed widget is appended if the dashboard has less widgets than its index",
+			before:  []string{"a", "b", "c"},
+			after:   []string{"a", "b"},
+			current: []string{"a"},
+			want:    []string{"a", "c"},
+		},
+		{
+			name:    "removed widgets are restored in the order of their indices",
+			before:  []string{"a", "b", "c", "d"},
+			after:   []string{"a", "d"},
+			current: []string{"a", "d", "e"},
+			want:    []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:    "changed widget is restored at its current index",
+			before:  []string{"a", "b"},
+			after:   []string{"a", "b2"},
+			current: []string{"b2", "a"},
+			want:    []string{"b", "a"},
+		},
+		{
+			name:    "changes of other widgets are kept",
+			before:  []string{"a", "b", "c"},
+			after:   []string{"a", "b2", "c"},
+			current: []string{"a2", "b2"},
+			want:    []string{"a2", "b"},
+		},
+		{
+			name:    "added widget is removed after the widgets were reordered",
+			before:  []string{"a", "b"},
+			after:   []string{"a", "b", "c"},
+			current: []string{"c", "b", "a"},
+			want:    []string{"b", "a"},
+		},
+		{
+			name:    "redo removes the restored widget again",
+			before:  []string{"a", "b", "c"},
+			after:   []string{"a", "c"},
+			current: []string{"a", "b", "c", "d"},
+			redo:    true,
+			want:    []string{"a", "c", "d"},
+		},
+		{
+			name:    "redo restores the added widget at its index",
+			before:  []string{"a", "c"},
+			after:   []string{"a", "b", "c"},
+			current: []string{"a", "c", "d"},
+			redo:    true,
+			want:    []string{"a", "b", "c", "d"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := Dashboard{Id: primitive.NewObjectID(), Widgets: testWidgets(test.before...)}
+			after := before
+			after.Widgets = testWidgets(test.after...)
+			entry := newUndoEntry(EventWidgetChanged, before, after, "", "user")
+			if entry == nil {
+				t.Fatal("expected an undo entry")
+			}
+			state := entry.Before
+			if test.redo {
+				state = entry.After
+			}
+			dash := before
+			dash.Widgets = testWidgets(test.current...)
+			state.apply(&dash)
+			if got := widgetNames(dash.Widgets); !slices.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewUndoEntry(t *testing.T) {
+	x, y, w, h := 0, 0, 4, 2
+	tests := []struct {
+		name   string
+		change func(dash *Dashboard) error
+		want   bool
+	}{
+		{
+			name:   "no change",
+			change: func(dash *Dashboard) error { return nil },
+		},
+		{
+			name: "nested property changed in place",
+			change: func(dash *Dashboard) error {
+				return dash.updateWidget(10, "query.limit", dash.Widgets[1].Id.Hex())
+			},
+			want: true,
+		},
+		{
+			name: "property list element changed in place",
+			change: func(dash *Dashboard) error {
+				dash.Widgets[1].Properties.(map[string]interface{})["series"].([]interface{})[0] = "other"
+				return nil
+			},
+			want: true,
+		},
+		{
+			name: "breakpoint layout changed in place",
+			change: func(dash *Dashboard) error {
+				return setBreakpointLayouts(dash.Widgets, Breakpoint{Name: "sm", Columns: 6}, map[int]WidgetPosition{
+					1: {Id: dash.Widgets[1].Id, X: &x, Y: &y, W: &w, H: &h},
+				})
+			},
+			want: true,
+		},
+	}
+	grid := Config.Grid
+	Config.Grid = testGrid()
+	defer func() { Config.Grid = grid }()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dash := Dashboard{Id: primitive.NewObjectID(), Name: "dashboard", Widgets: testWidgets("a", "b")}
+			dash.Widgets[1].Properties = map[string]interface{}{
+				"query":  map[string]interface{}{"limit": 5},
+				"series": []interface{}{"temperature"},
+			}
+			dash.Widgets[1].Layouts = map[string]BreakpointLayout{"sm": {X: 2, Y: 0, W: 4, H: 2}}
+			before := dash.clone()
+			err := test.change(&dash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry := newUndoEntry(EventWidgetChanged, before, dash, "", "user")
+			if got := entry != nil; got != test.want {
+				t.Fatalf("got undo entry %v, want %v", got, test.want)
+			}
+			if entry == nil {
+				return
+			}
+			entry.Before.apply(&dash)
+			if !reflect.DeepEqual(dash.Widgets, before.Widgets) {
+				t.Errorf("undo restored %+v, want %+v", dash.Widgets, before.Widgets)
+			}
+		})
+	}
+}
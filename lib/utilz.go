@@ -19,20 +19,27 @@
 package lib
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func getUserId(c *gin.Context) (userId string) {
-	userId = c.GetHeader("X-UserId")
-	if userId == "" {
-		userId = "testUser"
-	}
-	strings.Replace(userId, "\"", "", -1)
-	return
+func getUserId(c *gin.Context) string {
+	return c.GetString(userIdContextKey)
+}
+
+func getUserRoles(c *gin.Context) []string {
+	return c.GetStringSlice(userRolesContextKey)
+}
+
+// abortWithError writes the error response directly, gin_mw.ErrorHandler skips aborted requests.
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.String(GetStatusCode(err), err.Error())
+	c.Abort()
 }
 
 func removeAt[T any](list []T, index int) []T {
@@ -44,7 +51,12 @@ func insertAt[T any](list []T, value T, index int) []T {
 	return append(list[:index], listWithValue...)
 }
 
+// parseModifiedSince returns nil if the request has an If-None-Match header, which takes precedence and is evaluated by
+// conditionalGetHandler.
 func parseModifiedSince(c *gin.Context) *time.Time {
+	if c.GetHeader("If-None-Match") != "" {
+		return nil
+	}
 	str := c.GetHeader("If-Modified-Since")
 	if len(str) == 0 {
 		return nil
@@ -56,7 +68,15 @@ func parseModifiedSince(c *gin.Context) *time.Time {
 	return &t
 }
 
-func addCacheControlHeaders(c *gin.Context, t time.Time) {
+func addLastModifiedHeader(c *gin.Context, t time.Time) {
 	c.Header("Last-Modified", t.Format(http.TimeFormat))
-	c.Header("Cache-Control", "no-store")
+}
+
+// bindError classifies request body decoding errors, bodies exceeding the size limit are reported as 413.
+func bindError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return errors.Join(ErrPayloadTooLarge, fmt.Errorf("request body exceeds limit of %d bytes", maxBytesErr.Limit))
+	}
+	return ErrBadRequest
 }
@@ -0,0 +1,278 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const (
+	VariableTypeTimeRange  = "time_range"
+	VariableTypeDevice     = "device"
+	VariableTypeStringList = "string_list"
+)
+
+// DashboardVariable is referenced by widgets as "${name}" anywhere inside their properties.
+type DashboardVariable struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type" enums:"time_range,device,string_list"`
+	Default interface{} `json:"default,omitempty" swaggertype:"object"`
+}
+
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+var variableReferencePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// typedVariableValue converts a json or bson value into the go type of the variable type.
+func typedVariableValue(variableType string, value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	switch variableType {
+	case VariableTypeTimeRange:
+		result := TimeRange{}
+		err = json.Unmarshal(encoded, &result)
+		return result, err
+	case VariableTypeDevice:
+		result := ""
+		err = json.Unmarshal(encoded, &result)
+		return result, err
+	case VariableTypeStringList:
+		result := []string{}
+		err = json.Unmarshal(encoded, &result)
+		return result, err
+	default:
+		return nil, fmt.Errorf("unknown variable type %q", variableType)
+	}
+}
+
+func validateVariables(variables []DashboardVariable) error {
+	seen := map[string]bool{}
+	for _, variable := range variables {
+		if !variableNamePattern.MatchString(variable.Name) {
+			return errors.Join(ErrUnprocessableEntity, fmt.Errorf("invalid variable name %q", variable.Name))
+		}
+		if seen[variable.Name] {
+			return errors.Join(ErrUnprocessableEntity, fmt.Errorf("duplicate variable %q", variable.Name))
+		}
+		seen[variable.Name] = true
+		switch variable.Type {
+		case VariableTypeTimeRange, VariableTypeDevice, VariableTypeStringList:
+		default:
+			return errors.Join(ErrUnprocessableEntity, fmt.Errorf("unknown type %q of variable %q", variable.Type, variable.Name))
+		}
+		if variable.Default != nil {
+			_, err := typedVariableValue(variable.Type, variable.Default)
+			if err != nil {
+				return errors.Join(ErrUnprocessableEntity, fmt.Errorf("invalid default of variable %q", variable.Name), err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkVariableReferences ensures every variable referenced by the widget is defined by the dashboard.
+func (this *Dashboard) checkVariableReferences(widget Widget) error {
+	defined := map[string]bool{}
+	for _, variable := range this.Variables {
+		defined[variable.Name] = true
+	}
+	for _, name := range variableReferences(widget.Properties) {
+		if !defined[name] {
+			return errors.Join(ErrUnprocessableEntity, fmt.Errorf("widget %q references undefined variable %q", widget.Name, name))
+		}
+	}
+	return nil
+}
+
+// validateDashboardVariables validates the variable definitions and all references of the widgets.
+func (this *Dashboard) validateDashboardVariables() error {
+	err := validateVariables(this.Variables)
+	if err != nil {
+		return err
+	}
+	for _, widget := range this.Widgets {
+		err = this.checkVariableReferences(widget)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func variableReferences(value interface{}) []string {
+	result := []string{}
+	walkStrings(reflect.ValueOf(value), func(s string) interface{} {
+		for _, match := range variableReferencePattern.FindAllStringSubmatch(s, -1) {
+			result = append(result, match[1])
+		}
+		return s
+	})
+	return result
+}
+
+// resolveVariables returns a copy of the dashboard with all variable references replaced. Values are taken from
+// the given query values, keyed by variable name, or from the variable default.
+func (this Dashboard) resolveVariables(values map[string][]string) (Dashboard, error) {
+	resolved := map[string]interface{}{}
+	for _, variable := range this.Variables {
+		var value interface{} = variable.Default
+		if raw, ok := values[variable.Name]; ok && len(raw) > 0 {
+			value = parseVariableValue(variable.Type, raw)
+		}
+		if value == nil {
+			resolved[variable.Name] = nil
+			continue
+		}
+		typed, err := typedVariableValue(variable.Type, value)
+		if err != nil {
+			return this, errors.Join(ErrBadRequest, fmt.Errorf("invalid value for variable %q", variable.Name), err)
+		}
+		resolved[variable.Name] = typed
+	}
+	widgets := make([]Widget, len(this.Widgets))
+	copy(widgets, this.Widgets)
+	for i := range widgets {
+		widgets[i].Properties = resolveProperties(widgets[i].Properties, resolved)
+	}
+	this.Widgets = widgets
+	return this, nil
+}
+
+// resolveProperties returns a copy of the properties with the references of the resolved variables replaced. A
+// property consisting of a single reference takes the typed value, references within text are replaced by text.
+func resolveProperties(properties interface{}, resolved map[string]interface{}) interface{} {
+	return walkStrings(reflect.ValueOf(properties), func(s string) interface{} {
+		if match := variableReferencePattern.FindStringSubmatch(s); match != nil && match[0] == s {
+			if value, ok := resolved[match[1]]; ok {
+				return value
+			}
+			return s
+		}
+		return variableReferencePattern.ReplaceAllStringFunc(s, func(reference string) string {
+			value, ok := resolved[reference[2:len(reference)-1]]
+			if !ok {
+				return reference
+			}
+			return variableText(value)
+		})
+	})
+}
+
+// exampleVariableValues returns the default of each variable, or an empty value of its type if it has no valid
+// default. Widget properties are validated with these values in place of the references.
+func exampleVariableValues(variables []DashboardVariable) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, variable := range variables {
+		if variable.Default != nil {
+			if value, err := typedVariableValue(variable.Type, variable.Default); err == nil {
+				result[variable.Name] = value
+				continue
+			}
+		}
+		switch variable.Type {
+		case VariableTypeTimeRange:
+			result[variable.Name] = TimeRange{}
+		case VariableTypeDevice:
+			result[variable.Name] = ""
+		case VariableTypeStringList:
+			result[variable.Name] = []string{}
+		}
+	}
+	return result
+}
+
+// parseVariableValue reads query values: time ranges as "from,to", string lists as repeated or comma separated values.
+func parseVariableValue(variableType string, raw []string) interface{} {
+	switch variableType {
+	case VariableTypeTimeRange:
+		from, to, _ := strings.Cut(raw[0], ",")
+		return TimeRange{From: from, To: to}
+	case VariableTypeStringList:
+		result := []string{}
+		for _, entry := range raw {
+			for _, part := range strings.Split(entry, ",") {
+				if part != "" {
+					result = append(result, part)
+				}
+			}
+		}
+		return result
+	default:
+		return raw[0]
+	}
+}
+
+func variableText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case TimeRange:
+		return v.From + "," + v.To
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// walkStrings copies maps and slices and replaces every string with the result of f.
+func walkStrings(val reflect.Value, f func(string) interface{}) interface{} {
+	for val.Kind() == reflect.Interface || val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.String:
+		return f(val.String())
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return val.Interface()
+		}
+		result := map[string]interface{}{}
+		iter := val.MapRange()
+		for iter.Next() {
+			result[iter.Key().String()] = walkStrings(iter.Value(), f)
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return val.Interface()
+		}
+		result := make([]interface{}, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			result[i] = walkStrings(val.Index(i), f)
+		}
+		return result
+	case reflect.Invalid:
+		return nil
+	default:
+		return val.Interface()
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestResolveVariables(t *testing.T) {
+	variables := []DashboardVariable{
+		{Name: "range", Type: VariableTypeTimeRange, Default: map[string]interface{}{"from": "now-1d", "to": "now"}},
+		{Name: "device", Type: VariableTypeDevice, Default: "device-1"},
+		{Name: "services", Type: VariableTypeStringList},
+	}
+	tests := []struct {
+		name       string
+		properties interface{}
+		values     map[string][]string
+		want       interface{}
+	}{
+		{
+			name:       "reference in nested map is replaced by the default",
+			properties: map[string]interface{}{"query": map[string]interface{}{"time": "${range}"}},
+			want:       map[string]interface{}{"query": map[string]interface{}{"time": TimeRange{From: "now-1d", To: "now"}}},
+		},
+		{
+			name:       "reference in list of maps is replaced by the query value",
+			properties: map[string]interface{}{"series": []interface{}{map[string]interface{}{"device": "${device}"}, "static"}},
+			values:     map[string][]string{"device": {"device-2"}},
+			want:       map[string]interface{}{"series": []interface{}{map[string]interface{}{"device": "device-2"}, "static"}},
+		},
+		{
+			name:       "references in bson documents and arrays are replaced",
+			properties: bson.M{"devices": bson.A{bson.M{"id": "${device}"}}},
+			want:       map[string]interface{}{"devices": []interface{}{map[string]interface{}{"id": "device-1"}}},
+		},
+		{
+			name:       "references embedded in text are replaced by their text",
+			properties: map[string]interface{}{"title": map[string]interface{}{"text": "${device} from ${range}: ${services}"}},
+			values:     map[string][]string{"services": {"a,b", "c"}},
+			want:       map[string]interface{}{"title": map[string]interface{}{"text": "device-1 from now-1d,now: a,b,c"}},
+		},
+		{
+			name:       "variable without value is replaced by null",
+			properties: map[string]interface{}{"filter": []interface{}{"${services}"}},
+			want:       map[string]interface{}{"filter": []interface{}{nil}},
+		},
+		{
+			name:       "undefined references and other values are kept",
+			properties: map[string]interface{}{"nested": map[string]interface{}{"ref": "${unknown}", "count": 3, "on": true}},
+			want:       map[string]interface{}{"nested": map[string]interface{}{"ref": "${unknown}", "count": 3, "on": true}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dash := Dashboard{Variables: variables, Widgets: []Widget{{Properties: test.properties}}}
+			resolved, err := dash.resolveVariables(test.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resolved.Widgets[0].Properties; !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+			if !reflect.DeepEqual(dash.Widgets[0].Properties, test.properties) {
+				t.Errorf("properties of the dashboard were changed to %#v", dash.Widgets[0].Properties)
+			}
+		})
+	}
+}
+
+func TestResolveVariablesInvalidValue(t *testing.T) {
+	dash := Dashboard{Variables: []DashboardVariable{{Name: "range", Type: VariableTypeTimeRange, Default: "yesterday"}}}
+	if _, err := dash.resolveVariables(nil); err == nil {
+		t.Error("expected an error for an invalid default")
+	}
+}
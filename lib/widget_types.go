@@ -0,0 +1,157 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/SENERGY-Platform/dashboard/lib/log"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+const (
+	WidgetTypeModeStrict = "strict"
+	WidgetTypeModeWarn   = "warn"
+	WidgetTypeModeOff    = "off"
+)
+
+type WidgetType struct {
+	Type   string      `json:"type"`
+	Schema interface{} `json:"schema" swaggertype:"object"`
+}
+
+type widgetTypeRegistry struct {
+	schemas map[string]*jsonschema.Schema
+	types   []WidgetType
+}
+
+var widgetTypes = &widgetTypeRegistry{schemas: map[string]*jsonschema.Schema{}}
+
+// InitWidgetTypes loads the JSON Schema of every known widget type from Config.WidgetSchemaDir.
+// The file name without the .json extension is used as widget type.
+func InitWidgetTypes() {
+	switch Config.WidgetTypeMode {
+	case WidgetTypeModeStrict, WidgetTypeModeWarn, WidgetTypeModeOff:
+	default:
+		panic("invalid widget type mode: " + Config.WidgetTypeMode)
+	}
+	if Config.WidgetSchemaDir == "" {
+		log.Logger.Info("no widget schema dir configured, widget properties are not validated")
+		return
+	}
+	registry, err := loadWidgetTypes(Config.WidgetSchemaDir)
+	if err != nil {
+		panic("could not load widget types: " + err.Error())
+	}
+	widgetTypes = registry
+	log.Logger.Info("loaded widget types", "count", len(registry.types), "mode", Config.WidgetTypeMode)
+}
+
+func loadWidgetTypes(dir string) (*widgetTypeRegistry, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	registry := &widgetTypeRegistry{schemas: map[string]*jsonschema.Schema{}, types: []WidgetType{}}
+	compiler := jsonschema.NewCompiler()
+	for _, file := range files {
+		widgetType := strings.TrimSuffix(filepath.Base(file), ".json")
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		err = compiler.AddResource(file, doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		schema, err := compiler.Compile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		var raw interface{}
+		err = json.Unmarshal(content, &raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		registry.schemas[widgetType] = schema
+		registry.types = append(registry.types, WidgetType{Type: widgetType, Schema: raw})
+	}
+	return registry, nil
+}
+
+func listWidgetTypes() []WidgetType {
+	if widgetTypes.types == nil {
+		return []WidgetType{}
+	}
+	return widgetTypes.types
+}
+
+// validateWidget checks the widget properties against the schema of the widget type, with the references of the
+// variables replaced by their default or an empty value of their type.
+// Unknown widget types are handled according to Config.WidgetTypeMode.
+func validateWidget(widget Widget, variables []DashboardVariable) error {
+	schema, ok := widgetTypes.schemas[widget.Type]
+	if !ok {
+		switch Config.WidgetTypeMode {
+		case WidgetTypeModeStrict:
+			return errors.Join(ErrUnprocessableEntity, fmt.Errorf("unknown widget type %q", widget.Type))
+		case WidgetTypeModeWarn:
+			log.Logger.Warn("unknown widget type", "type", widget.Type, "widget_id", widget.Id.Hex())
+		}
+		return nil
+	}
+	properties := resolveProperties(widget.Properties, exampleVariableValues(variables))
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	// normalize bson and go values to plain json values
+	encoded, err := json.Marshal(properties)
+	if err != nil {
+		return errors.Join(ErrBadRequest, err)
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(encoded))
+	if err != nil {
+		return errors.Join(ErrBadRequest, err)
+	}
+	err = schema.Validate(instance)
+	if err != nil {
+		return errors.Join(ErrUnprocessableEntity, fmt.Errorf("invalid properties for widget type %q", widget.Type), err)
+	}
+	return nil
+}
+
+func validateWidgets(widgets []Widget, variables []DashboardVariable) error {
+	for _, widget := range widgets {
+		err := validateWidget(widget, variables)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
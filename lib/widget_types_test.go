@@ -0,0 +1,78 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testChartSchema = `{
+	"type": "object",
+	"properties": {
+		"time": {"type": "object", "properties": {"from": {"type": "string"}, "to": {"type": "string"}}, "required": ["from", "to"]},
+		"device": {"enum": ["device-1", "device-2"]},
+		"services": {"type": "array", "items": {"type": "string"}},
+		"title": {"type": "string"},
+		"limit": {"type": "number"}
+	}
+}`
+
+func TestValidateWidget(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "chart.json"), []byte(testChartSchema), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := loadWidgetTypes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := widgetTypes
+	widgetTypes = registry
+	defer func() { widgetTypes = original }()
+
+	variables := []DashboardVariable{
+		{Name: "range", Type: VariableTypeTimeRange, Default: map[string]interface{}{"from": "now-1d", "to": "now"}},
+		{Name: "device", Type: VariableTypeDevice, Default: "device-1"},
+		{Name: "other", Type: VariableTypeDevice, Default: "device-3"},
+		{Name: "services", Type: VariableTypeStringList},
+	}
+	tests := []struct {
+		name       string
+		properties map[string]interface{}
+		valid      bool
+	}{
+		{name: "literal values", properties: map[string]interface{}{"time": map[string]interface{}{"from": "a", "to": "b"}, "limit": 5}, valid: true},
+		{name: "time range variable for an object", properties: map[string]interface{}{"time": "${range}"}, valid: true},
+		{name: "device variable with a default of the enum", properties: map[string]interface{}{"device": "${device}"}, valid: true},
+		{name: "device variable with a default outside of the enum", properties: map[string]interface{}{"device": "${other}"}},
+		{name: "string list variable without default for an array", properties: map[string]interface{}{"services": "${services}"}, valid: true},
+		{name: "variables within text", properties: map[string]interface{}{"title": "${device} ${range}"}, valid: true},
+		{name: "time range variable for a number", properties: map[string]interface{}{"limit": "${range}"}},
+		{name: "invalid literal value", properties: map[string]interface{}{"limit": "five"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateWidget(Widget{Type: "chart", Properties: test.properties}, variables)
+			if valid := err == nil; valid != test.valid {
+				t.Errorf("got valid %v, want %v: %v", valid, test.valid, err)
+			}
+		})
+	}
+}
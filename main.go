@@ -19,7 +19,10 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/SENERGY-Platform/dashboard/lib/log"
 	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
@@ -34,11 +37,30 @@ func main() {
 		log.Logger.Warn("Error loading .env file", attributes.ErrorKey, err)
 	}
 
+	lib.LoadConfig()
+
 	if os.Getenv("SYNC") == "true" {
 		lib.Sync()
 	}
 
+	err = run()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// run keeps the deferred cleanup in reverse start order: the server drains first, then the outbox relay stops and
+// finally the database is disconnected.
+func run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	lib.InitWidgetTypes()
+	lib.InitEvents()
 	lib.InitDB()
 	defer lib.CloseDB()
-	lib.CreateServer()
+	lib.InitRateLimits()
+	lib.StartOutboxRelay()
+	defer lib.StopOutboxRelay()
+	return lib.CreateServer().Run(ctx)
 }